@@ -0,0 +1,58 @@
+package gocsv
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SetHeaderAliases lets a column name used by tags, the mapping or
+// SelectColumns match other header spellings, for files whose upstream
+// renamed a column. Keys are the canonical names and values the
+// alternatives, e.g. {"email": {"email_address"}}; the canonical name
+// itself is still tried first. It returns a CSVError if the header holds
+// more than one spelling of the same column, since it would be ambiguous
+// which to read. A nil map removes the aliases.
+func (r *CSVReader) SetHeaderAliases(aliases map[string][]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	copied := make(map[string][]string, len(aliases))
+	for canonical, alternatives := range aliases {
+		if err := r.checkAliases(canonical, alternatives); err != nil {
+			return err
+		}
+		copied[canonical] = slices.Clone(alternatives)
+	}
+	if aliases == nil {
+		copied = nil
+	}
+	r.aliases = copied
+	r.plans = nil
+	return nil
+}
+
+// checkAliases reports an error when the header holds more than one of
+// canonical and its alternatives
+func (r *CSVReader) checkAliases(canonical string, alternatives []string) error {
+	if r.noHeader {
+		return nil
+	}
+	var present []string
+	seen := make(map[int]bool)
+	for _, name := range append([]string{canonical}, alternatives...) {
+		if index, ok := r.headerIndex(name); ok && !seen[index] {
+			seen[index] = true
+			present = append(present, r.headers[index])
+		}
+	}
+	if len(present) < 2 {
+		return nil
+	}
+	return &CSVError{
+		Field:   "headers",
+		Value:   strings.Join(present, ","),
+		Type:    "alias",
+		Wrapped: fmt.Errorf("ambiguous headers for column %q: %s", canonical, strings.Join(present, ", ")),
+	}
+}
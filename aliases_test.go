@@ -0,0 +1,56 @@
+package gocsv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type aliasRow struct {
+	Name  string `csv:"name"`
+	Email string `csv:"email"`
+}
+
+func TestSetHeaderAliases(t *testing.T) {
+	aliases := map[string][]string{"email": {"email_address", "mail"}}
+	for _, content := range []string{
+		"name,email\nAlice,a@example.com\n",
+		"name,email_address\nAlice,a@example.com\n",
+		"mail,name\na@example.com,Alice\n",
+	} {
+		reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to create reader: %v", err)
+		}
+		if err := reader.SetHeaderAliases(aliases); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var row aliasRow
+		if err := reader.ReadNext(&row); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if row.Name != "Alice" || row.Email != "a@example.com" {
+			t.Errorf("unexpected row for %q: %+v", content, row)
+		}
+	}
+}
+
+func TestSetHeaderAliasesAmbiguous(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("email,email_address\na,b\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	err = reader.SetHeaderAliases(map[string][]string{"email": {"email_address"}})
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || csvErr.Value != "email,email_address" {
+		t.Fatalf("expected an ambiguity CSVError, got %v", err)
+	}
+
+	if err := reader.SetHeaderAliases(map[string][]string{"mail": {"email_address"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reader.HasColumn("mail") {
+		t.Error("expected mail to resolve through its alias")
+	}
+}
@@ -0,0 +1,37 @@
+package gocsv
+
+import (
+	"errors"
+	"io"
+)
+
+// DecodeFile reads every record of the CSV file at filePath into dest,
+// which must be a pointer to a slice of structs or struct pointers. The
+// file is always closed, and a close error is reported if decoding
+// succeeded.
+func DecodeFile(filePath string, dest interface{}, opts ...Option) (err error) {
+	reader, err := NewCSVReaderWithOptions(filePath, opts...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, reader.Close())
+	}()
+
+	return reader.ReadAll(dest)
+}
+
+// DecodeReader reads every record from src into dest like DecodeFile.
+// src is not closed; that stays the caller's responsibility.
+func DecodeReader(src io.Reader, dest interface{}, opts ...Option) (err error) {
+	reader, err := newCSVReader(src, opts)
+	if err != nil {
+		return err
+	}
+	// Release the decompressor of WithDecompression, but not src
+	defer func() {
+		err = errors.Join(err, reader.closeDecompressor())
+	}()
+
+	return reader.ReadAll(dest)
+}
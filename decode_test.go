@@ -0,0 +1,47 @@
+package gocsv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeFile(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "decode.csv")
+	if err := os.WriteFile(tmpFile, []byte("string_field;int_field\na;1\nb;2\n"), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	var rows []TestStruct
+	if err := DecodeFile(tmpFile, &rows, WithDelimiter(';')); err != nil {
+		t.Fatalf("DecodeFile failed: %v", err)
+	}
+	if len(rows) != 2 || rows[0].StringField != "a" || rows[1].IntField != 2 {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+
+	var ints []int
+	if err := DecodeFile(tmpFile, &ints); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct, got %v", err)
+	}
+
+	if err := DecodeFile(filepath.Join(t.TempDir(), "missing.csv"), &rows); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist for a missing file, got %v", err)
+	}
+}
+
+func TestDecodeReader(t *testing.T) {
+	var rows []*TestStruct
+	if err := DecodeReader(strings.NewReader("string_field,int_field\na,1\n"), &rows); err != nil {
+		t.Fatalf("DecodeReader failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0].StringField != "a" || rows[0].IntField != 1 {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+
+	if err := DecodeReader(strings.NewReader(""), &rows); !errors.Is(err, ErrEmptyFile) {
+		t.Errorf("expected ErrEmptyFile, got %v", err)
+	}
+}
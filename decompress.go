@@ -0,0 +1,171 @@
+package gocsv
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Compression selects how a CSVReader decompresses its input, see
+// WithDecompression
+type Compression int
+
+const (
+	// CompressionNone reads the input as is
+	CompressionNone Compression = iota
+	// CompressionAuto detects gzip, bzip2 and zstd input from its leading
+	// magic bytes and reads anything else as is
+	CompressionAuto
+	// CompressionGzip reads gzip input
+	CompressionGzip
+	// CompressionBzip2 reads bzip2 input
+	CompressionBzip2
+	// CompressionZstd reads zstd input. It needs a build with the zstd tag,
+	// which pulls in github.com/klauspost/compress.
+	CompressionZstd
+)
+
+// String returns the name of the compression format
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionAuto:
+		return "auto"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionBzip2:
+		return "bzip2"
+	case CompressionZstd:
+		return "zstd"
+	}
+	return "Compression(" + strconv.Itoa(int(c)) + ")"
+}
+
+// newZstdReader is set by the zstd build tag
+var newZstdReader func(io.Reader) (io.ReadCloser, error)
+
+var errZstdUnsupported = errors.New("zstd support requires building with -tags zstd")
+
+// Magic bytes that start each compressed format
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// WithDecompression decompresses the input before it is parsed, for
+// sources such as an HTTP body or a file whose name says nothing about
+// its format. CompressionAuto recognizes the format from the data itself.
+// Close releases the decompressor, and Reset starts a fresh one.
+func WithDecompression(c Compression) Option {
+	return func(o *options) error {
+		if c < CompressionNone || c > CompressionZstd {
+			return &CSVError{
+				Field:   "decompression",
+				Value:   c.String(),
+				Type:    "Compression",
+				Wrapped: fmt.Errorf("unknown compression"),
+			}
+		}
+		if c == CompressionZstd && newZstdReader == nil {
+			return &CSVError{
+				Field:   "decompression",
+				Value:   c.String(),
+				Type:    "Compression",
+				Wrapped: errZstdUnsupported,
+			}
+		}
+		o.compression = c
+		return nil
+	}
+}
+
+// A bzip2 stream continues its magic with a block size digit and then the
+// magic of its first block, or of the end of an empty stream. Requiring
+// them keeps a plain header such as "BZhName" from being taken for bzip2.
+var (
+	bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	bzip2EndMagic   = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+)
+
+// detectCompression peeks at the start of src for a known magic number
+func detectCompression(src *bufio.Reader) Compression {
+	// A short or failing peek returns what it has, which is enough to tell
+	// that no magic number is there; the error resurfaces on the next read
+	head, _ := src.Peek(len(bzip2Magic) + 1 + len(bzip2BlockMagic))
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return CompressionGzip
+	case isBzip2(head):
+		return CompressionBzip2
+	case bytes.HasPrefix(head, zstdMagic):
+		return CompressionZstd
+	}
+	return CompressionNone
+}
+
+// isBzip2 reports whether head starts with a full bzip2 stream header
+func isBzip2(head []byte) bool {
+	n := len(bzip2Magic)
+	if len(head) < n+1+len(bzip2BlockMagic) || !bytes.HasPrefix(head, bzip2Magic) {
+		return false
+	}
+	if head[n] < '1' || head[n] > '9' {
+		return false
+	}
+	rest := head[n+1:]
+	return bytes.HasPrefix(rest, bzip2BlockMagic) || bytes.HasPrefix(rest, bzip2EndMagic)
+}
+
+// decompress wraps src in the configured decompressor. The one of an
+// earlier open is left to Reset, which closes it once the reopen succeeds.
+func (r *CSVReader) decompress(src io.Reader) (io.Reader, error) {
+	r.decompressor = nil
+
+	compression := r.opts.compression
+	if compression == CompressionAuto {
+		buffered := bufio.NewReader(src)
+		compression = detectCompression(buffered)
+		src = buffered
+	}
+
+	switch compression {
+	case CompressionGzip:
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			return nil, err
+		}
+		r.decompressor = gz
+		return gz, nil
+	case CompressionBzip2:
+		return bzip2.NewReader(src), nil
+	case CompressionZstd:
+		if newZstdReader == nil {
+			return nil, errZstdUnsupported
+		}
+		zr, err := newZstdReader(src)
+		if err != nil {
+			return nil, err
+		}
+		r.decompressor = zr
+		return zr, nil
+	}
+	return src, nil
+}
+
+// closeDecompressor releases the current decompressor, if any. The
+// decompressors do not close the source they read from.
+func (r *CSVReader) closeDecompressor() error {
+	if r.decompressor == nil {
+		return nil
+	}
+	err := r.decompressor.Close()
+	r.decompressor = nil
+	return err
+}
@@ -0,0 +1,144 @@
+package gocsv
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"io"
+	"testing"
+)
+
+// bzip2Sample is "string_field,int_field\nAlice,30\n" compressed with
+// bzip2, which the standard library can only decompress
+const bzip2Sample = "QlpoOTFBWSZTWWsbdKkAAA7fgAAQAARIACAAAACPpRwAIAAhqNGjRtJkKGmmADeGRVWnMAoCPZ8J7Alg55XxdyRThQkGsbdKkA=="
+
+func gzipBytes(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(gz, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWithDecompression(t *testing.T) {
+	bz, err := base64.StdEncoding.DecodeString(bzip2Sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := []byte("string_field,int_field\nAlice,30\n")
+
+	tests := []struct {
+		name        string
+		data        []byte
+		compression Compression
+	}{
+		{"auto gzip", gzipBytes(t, string(plain)), CompressionAuto},
+		{"auto bzip2", bz, CompressionAuto},
+		{"auto plain", plain, CompressionAuto},
+		{"gzip", gzipBytes(t, string(plain)), CompressionGzip},
+		{"bzip2", bz, CompressionBzip2},
+		{"none", plain, CompressionNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewCSVReaderBytes(tt.data, WithDecompression(tt.compression))
+			if err != nil {
+				t.Fatalf("failed to create reader: %v", err)
+			}
+			defer reader.Close()
+
+			var row TestStruct
+			for pass := 0; pass < 2; pass++ {
+				if err := reader.ReadNext(&row); err != nil {
+					t.Fatalf("pass %d: unexpected error: %v", pass, err)
+				}
+				if row.StringField != "Alice" || row.IntField != 30 {
+					t.Errorf("pass %d: unexpected value: %+v", pass, row)
+				}
+				if err := reader.Reset(); err != nil {
+					t.Fatalf("failed to reset: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// countingCloser counts how often it is closed
+type countingCloser struct {
+	io.Reader
+	closed int
+}
+
+func (c *countingCloser) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestDecompressorReleased(t *testing.T) {
+	var decompressors []*countingCloser
+	defer func(prev func(io.Reader) (io.ReadCloser, error)) { newZstdReader = prev }(newZstdReader)
+	newZstdReader = func(src io.Reader) (io.ReadCloser, error) {
+		c := &countingCloser{Reader: src}
+		decompressors = append(decompressors, c)
+		return c, nil
+	}
+
+	src := &countingCloser{Reader: bytes.NewReader([]byte("string_field,int_field\nAlice,30\n"))}
+	var rows []TestStruct
+	if err := DecodeReader(src, &rows, WithDecompression(CompressionZstd)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	src.Reader = bytes.NewReader([]byte("name\nAlice\n"))
+	if _, err := InferSchema(src, 0, WithDecompression(CompressionZstd)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(decompressors) != 2 || decompressors[0].closed != 1 || decompressors[1].closed != 1 {
+		t.Errorf("expected each decompressor closed once, got %d decompressors", len(decompressors))
+	}
+	if src.closed != 0 {
+		t.Error("expected the caller's source to be left open")
+	}
+}
+
+func TestDetectCompressionPlainBZh(t *testing.T) {
+	// A header starting with the bzip2 magic is still plain CSV
+	reader, err := NewCSVReaderBytes([]byte("BZhName\nx\n"), WithDecompression(CompressionAuto))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var row struct {
+		Name string `csv:"BZhName"`
+	}
+	if err := reader.ReadNext(&row); err != nil || row.Name != "x" {
+		t.Errorf("expected plain input, got %+v, %v", row, err)
+	}
+}
+
+func TestWithDecompressionErrors(t *testing.T) {
+	_, err := NewCSVReaderBytes([]byte("name\nAlice\n"), WithDecompression(CompressionGzip))
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || csvErr.Field != "decompression" {
+		t.Errorf("expected a decompression error for plain input, got %v", err)
+	}
+
+	if _, err := NewCSVReaderBytes(nil, WithDecompression(Compression(42))); err == nil {
+		t.Error("expected an unknown compression to be rejected")
+	}
+
+	if newZstdReader == nil {
+		if _, err := NewCSVReaderBytes(nil, WithDecompression(CompressionZstd)); !errors.Is(err, errZstdUnsupported) {
+			t.Errorf("expected zstd to be unsupported without the build tag, got %v", err)
+		}
+		zstdFrame := append(append([]byte(nil), zstdMagic...), 0, 0)
+		if _, err := NewCSVReaderBytes(zstdFrame, WithDecompression(CompressionAuto)); !errors.Is(err, errZstdUnsupported) {
+			t.Errorf("expected detected zstd to be unsupported without the build tag, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package gocsv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Dialect describes the syntax of a CSV file.
+//
+// Delimiter, Comment and LazyQuotes map directly onto encoding/csv. Quote
+// and Escape are not supported by encoding/csv, so when Quote is not '"'
+// or Escape is set the input is rewritten to standard CSV before it is
+// parsed. The rewrite recognizes a quote only at the start of a field and
+// treats a doubled quote inside a quoted field as a literal quote. A '"'
+// inside a quoted field is kept as text, but elsewhere it is passed to
+// encoding/csv unchanged, so it still needs LazyQuotes and cannot start a
+// field.
+type Dialect struct {
+	// Delimiter separates fields. Zero means ','.
+	Delimiter rune
+	// Quote encloses fields containing delimiters or line breaks. Zero
+	// means '"'.
+	Quote rune
+	// Escape, when set, makes the following character inside a quoted
+	// field literal, as in MySQL's \" exports.
+	Escape rune
+	// Comment starts a comment line. Zero disables comments.
+	Comment rune
+	// LazyQuotes tolerates quotes that appear inside unquoted fields.
+	LazyQuotes bool
+}
+
+// WithDialect applies every setting of d before the header is read
+func WithDialect(d Dialect) Option {
+	return func(o *options) error {
+		d = d.withDefaults()
+		if err := d.validate(); err != nil {
+			return err
+		}
+		o.delimiter = d.Delimiter
+		o.quote = d.Quote
+		o.escape = d.Escape
+		o.comment = d.Comment
+		o.lazy = d.LazyQuotes
+		return nil
+	}
+}
+
+// SetDialect applies d to the remaining records. The quote and escape
+// characters are fixed when the input is opened, so changing them here
+// fails; use WithDialect to set them. Like SetDelimiter and SetComment,
+// changing the delimiter or comment fails with ErrHeaderAlreadyRead once
+// a header has been read.
+func (r *CSVReader) SetDialect(d Dialect) error {
+	d = d.withDefaults()
+	if err := d.validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d.Quote != r.opts.quoteRune() || d.Escape != r.opts.escape {
+		return &CSVError{
+			Field:   "dialect",
+			Value:   fmt.Sprintf("quote %q, escape %q", d.Quote, d.Escape),
+			Type:    "Dialect",
+			Wrapped: fmt.Errorf("quote and escape characters can only be set with WithDialect"),
+		}
+	}
+	if d.Delimiter != r.opts.delimiter {
+		if err := r.checkHeaderUnread("delimiter", d.Delimiter); err != nil {
+			return err
+		}
+	}
+	if d.Comment != r.opts.comment {
+		if err := r.checkHeaderUnread("comment", d.Comment); err != nil {
+			return err
+		}
+	}
+	r.reader.Comma = d.Delimiter
+	r.reader.Comment = d.Comment
+	r.reader.LazyQuotes = d.LazyQuotes
+	r.opts.delimiter = d.Delimiter
+	r.opts.comment = d.Comment
+	r.opts.lazy = d.LazyQuotes
+	return nil
+}
+
+func (d Dialect) withDefaults() Dialect {
+	if d.Delimiter == 0 {
+		d.Delimiter = ','
+	}
+	if d.Quote == 0 {
+		d.Quote = '"'
+	}
+	return d
+}
+
+func (d Dialect) validate() error {
+	if err := validateDelimiter(d.Delimiter); err != nil {
+		return err
+	}
+	if err := validateComment(d.Comment); err != nil {
+		return err
+	}
+
+	special := []rune{d.Delimiter, d.Comment}
+	for _, c := range []struct {
+		field string
+		value rune
+	}{{"quote", d.Quote}, {"escape", d.Escape}} {
+		if c.value == 0 {
+			continue
+		}
+		if c.value == '\n' || c.value == '\r' || !utf8.ValidRune(c.value) || c.value == utf8.RuneError {
+			return &CSVError{
+				Field:   c.field,
+				Value:   string(c.value),
+				Type:    "rune",
+				Wrapped: fmt.Errorf("invalid %s %q", c.field, c.value),
+			}
+		}
+		for _, s := range special {
+			if c.value == s {
+				return &CSVError{
+					Field:   c.field,
+					Value:   string(c.value),
+					Type:    "rune",
+					Wrapped: fmt.Errorf("%s %q conflicts with the delimiter or comment", c.field, c.value),
+				}
+			}
+		}
+	}
+	if d.Escape != 0 && d.Escape == d.Quote {
+		return &CSVError{
+			Field:   "escape",
+			Value:   string(d.Escape),
+			Type:    "rune",
+			Wrapped: fmt.Errorf("escape %q is the same as the quote; doubled quotes are always literal", d.Escape),
+		}
+	}
+	if d.Delimiter == d.Comment {
+		return &CSVError{
+			Field:   "comment",
+			Value:   string(d.Comment),
+			Type:    "rune",
+			Wrapped: fmt.Errorf("comment %q is the same as the delimiter", d.Comment),
+		}
+	}
+	return nil
+}
+
+// quoteRune returns the quote character in effect
+func (o *options) quoteRune() rune {
+	if o.quote == 0 {
+		return '"'
+	}
+	return o.quote
+}
+
+// needsQuoteRewrite reports whether the dialect can only be parsed by
+// rewriting the input for encoding/csv
+func (o *options) needsQuoteRewrite() bool {
+	return o.quoteRune() != '"' || o.escape != 0
+}
+
+// quoteReader rewrites input using a custom quote or escape character into
+// standard CSV. It reads the delimiter and comment from opts on every call
+// so later SetDelimiter and SetComment calls are honored.
+type quoteReader struct {
+	src        *bufio.Reader
+	opts       *options
+	inQuotes   bool
+	fieldStart bool
+	lineStart  bool
+	inComment  bool
+	pending    []byte
+}
+
+func newQuoteReader(src *bufio.Reader, opts *options) *quoteReader {
+	return &quoteReader{src: src, opts: opts, fieldStart: true, lineStart: true}
+}
+
+func (q *quoteReader) Read(p []byte) (int, error) {
+	var err error
+	for len(q.pending) < len(p) && err == nil {
+		err = q.next()
+	}
+
+	n := copy(p, q.pending)
+	q.pending = q.pending[n:]
+	if n > 0 {
+		return n, nil
+	}
+	return 0, err
+}
+
+// next translates one rune of input
+func (q *quoteReader) next() error {
+	c, _, err := q.src.ReadRune()
+	if err != nil {
+		if err == io.EOF && q.inQuotes {
+			// Leave the field unterminated so encoding/csv reports it
+			q.inQuotes = false
+		}
+		return err
+	}
+
+	quote, escape := q.opts.quoteRune(), q.opts.escape
+	switch {
+	case q.inComment:
+		q.emit(c)
+	case q.inQuotes && escape != 0 && c == escape:
+		escaped, _, err := q.src.ReadRune()
+		if err != nil {
+			return err
+		}
+		q.emitQuoted(escaped)
+	case q.inQuotes && c == quote:
+		following, _, err := q.src.ReadRune()
+		if err == nil && following == quote {
+			q.emitQuoted(quote)
+			return nil
+		}
+		if err == nil {
+			q.src.UnreadRune()
+		}
+		q.inQuotes = false
+		q.emit('"')
+	case q.inQuotes:
+		q.emitQuoted(c)
+	case q.lineStart && q.opts.comment != 0 && c == q.opts.comment:
+		q.inComment = true
+		q.emit(c)
+	case q.fieldStart && c == quote:
+		q.inQuotes = true
+		q.emit('"')
+	default:
+		q.emit(c)
+	}
+
+	if q.inQuotes {
+		q.fieldStart, q.lineStart = false, false
+		return nil
+	}
+	q.lineStart = c == '\n'
+	q.fieldStart = c == '\n' || c == '\r' || c == q.opts.delimiter
+	if c == '\n' {
+		q.inComment = false
+	}
+	return nil
+}
+
+// emitQuoted writes c inside a quoted field, doubling '"' as encoding/csv
+// expects
+func (q *quoteReader) emitQuoted(c rune) {
+	if c == '"' {
+		q.pending = append(q.pending, '"')
+	}
+	q.emit(c)
+}
+
+func (q *quoteReader) emit(c rune) {
+	q.pending = utf8.AppendRune(q.pending, c)
+}
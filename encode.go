@@ -0,0 +1,33 @@
+package gocsv
+
+import (
+	"bytes"
+	"errors"
+	"os"
+)
+
+// EncodeFile writes src, a slice of structs or struct pointers, to a new
+// CSV file at filePath: a header derived from the element type followed by
+// one row per element. An existing file is truncated. The file is always
+// closed, and a close error is reported if encoding succeeded.
+func EncodeFile(filePath string, src interface{}) (err error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return &CSVError{Field: "file", Value: filePath, Wrapped: err}
+	}
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	return NewCSVWriter(file).WriteAll(src)
+}
+
+// Marshal encodes src like EncodeFile and returns the CSV as bytes, for
+// callers that send it elsewhere without a temporary file
+func Marshal(src interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewCSVWriter(&buf).WriteAll(src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,62 @@
+package gocsv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeFile(t *testing.T) {
+	type person struct {
+		Name string `csv:"name"`
+		Age  int    `csv:"age"`
+	}
+	people := []person{{"Alice", 30}, {"Bob", 25}}
+
+	tmpFile := filepath.Join(t.TempDir(), "people.csv")
+	if err := EncodeFile(tmpFile, people); err != nil {
+		t.Fatalf("EncodeFile failed: %v", err)
+	}
+	data, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to read back file: %v", err)
+	}
+	if expected := "name,age\nAlice,30\nBob,25\n"; string(data) != expected {
+		t.Errorf("got %q, want %q", data, expected)
+	}
+
+	var decoded []person
+	if err := DecodeFile(tmpFile, &decoded); err != nil {
+		t.Fatalf("DecodeFile failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, people) {
+		t.Errorf("round trip mismatch: got %+v, want %+v", decoded, people)
+	}
+
+	if err := EncodeFile(tmpFile, 42); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct, got %v", err)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	type item struct {
+		SKU string  `csv:"sku"`
+		Qty *int    `csv:"qty"`
+		Tag float64 `csv:"-"`
+	}
+	qty := 3
+
+	data, err := Marshal([]*item{{SKU: "a-1", Qty: &qty}, {SKU: "b-2"}})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if expected := "sku,qty\na-1,3\nb-2,\n"; string(data) != expected {
+		t.Errorf("got %q, want %q", data, expected)
+	}
+
+	if _, err := Marshal([]string{"x"}); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct, got %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+package gocsv
+
+import (
+	"golang.org/x/text/encoding"
+)
+
+// NewCSVReaderWithEncoding creates a new CSV reader for a file written in
+// enc, such as unicode.UTF16(unicode.LittleEndian, unicode.UseBOM) or
+// charmap.Windows1252. The content is decoded to UTF-8 before parsing.
+func NewCSVReaderWithEncoding(filePath string, enc encoding.Encoding) (*CSVReader, error) {
+	return NewCSVReaderWithOptions(filePath, WithEncoding(enc))
+}
+
+// WithEncoding decodes the input from enc to UTF-8 before it is parsed.
+// A nil encoding keeps the default of UTF-8.
+func WithEncoding(enc encoding.Encoding) Option {
+	return func(o *options) error {
+		o.encoding = enc
+		return nil
+	}
+}
@@ -1,19 +1,128 @@
 package gocsv
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"io"
+)
 
+// ErrNotSeekable is returned by Reset when the underlying source cannot be
+// rewound
+var ErrNotSeekable = errors.New("reader is not seekable")
+
+// Sentinel errors wrapped by CSVError so callers can branch with errors.Is
+var (
+	// ErrEmptyFile means the input ended before a header row was read
+	ErrEmptyFile = errors.New("empty file")
+	// ErrNotPointer means a destination was not a non-nil pointer
+	ErrNotPointer = errors.New("not a non-nil pointer")
+	// ErrNotStruct means a destination or prototype was not a struct, or a
+	// slice destination did not hold structs
+	ErrNotStruct = errors.New("not a struct")
+	// ErrColumnMissing means a column needed by a struct field is absent
+	// from the header
+	ErrColumnMissing = errors.New("column missing")
+	// ErrHeaderAlreadyRead means a setting that affects how the header is
+	// parsed was changed after the header was read; pass the matching
+	// Option to the constructor instead
+	ErrHeaderAlreadyRead = errors.New("header already read")
+	// ErrNoMappableFields means a destination struct has no field a column
+	// could be read into, as when every field is unexported or tagged
+	// csv:"-"
+	ErrNoMappableFields = errors.New("no mappable fields")
+)
+
+// ErrStop can be returned by a ForEach callback to stop reading without
+// reporting an error
+var ErrStop = errors.New("stop iteration")
+
+// CSVError describes a failure to read, decode or write a value. For
+// malformed CSV reported by encoding/csv, Line and Column locate the error
+// and Wrapped holds the underlying cause, such as csv.ErrQuote.
 type CSVError struct {
 	Field   string
 	Value   string
 	Type    string
+	Row     int
+	Line    int
+	Column  int
 	Wrapped error
 }
 
 func (e *CSVError) Error() string {
+	prefix := ""
+	if e.Line > 0 && e.Column > 0 {
+		prefix = fmt.Sprintf("line %d, column %d: ", e.Line, e.Column)
+	} else if e.Line > 0 {
+		prefix = fmt.Sprintf("line %d: ", e.Line)
+	} else if e.Row > 0 {
+		prefix = fmt.Sprintf("row %d: ", e.Row)
+	}
+	if e.Wrapped != nil && e.Value == "" && e.Type == "" {
+		// Errors such as an empty file have no value to describe
+		return fmt.Sprintf("%sfield %s: %v", prefix, e.Field, e.Wrapped)
+	}
 	if e.Wrapped != nil {
-		return fmt.Sprintf("field %s: error converting value '%s' to %s: %v",
-			e.Field, e.Value, e.Type, e.Wrapped)
+		return fmt.Sprintf("%sfield %s: error converting value '%s' to %s: %v",
+			prefix, e.Field, e.Value, e.Type, e.Wrapped)
+	}
+	return fmt.Sprintf("%sfield %s: error with value '%s' of type %s",
+		prefix, e.Field, e.Value, e.Type)
+}
+
+// Unwrap returns the underlying error, so errors.Is and errors.As can
+// match causes such as strconv.ErrSyntax
+func (e *CSVError) Unwrap() error {
+	return e.Wrapped
+}
+
+// RowError records a row that could not be decoded by ReadAllLenient
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e RowError) Error() string {
+	// A CSVError already names its line
+	var csvErr *CSVError
+	if errors.As(e.Err, &csvErr) && csvErr.Line > 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError aggregates several errors, such as the row errors collected by
+// ReadAllLenient, so that errors.Is and errors.As can match any of them
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	return errors.Join(e.Errors...).Error()
+}
+
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// JoinRowErrors combines the row errors returned by ReadAllLenient into a
+// single *MultiError, or returns nil if there are none
+func JoinRowErrors(rowErrors []RowError) error {
+	if len(rowErrors) == 0 {
+		return nil
 	}
-	return fmt.Sprintf("field %s: error with value '%s' of type %s",
-		e.Field, e.Value, e.Type)
+	errs := make([]error, len(rowErrors))
+	for i, rowErr := range rowErrors {
+		errs[i] = rowErr
+	}
+	return &MultiError{Errors: errs}
+}
+
+// IsEOF reports whether err marks the end of the input
+func IsEOF(err error) bool {
+	return errors.Is(err, io.EOF)
 }
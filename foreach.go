@@ -0,0 +1,40 @@
+package gocsv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+)
+
+// ForEach decodes the remaining records one at a time and calls fn with
+// the zero-based row index and a pointer to the decoded struct, without
+// collecting the rows in a slice. prototype, a struct or struct pointer,
+// gives the row type; a single instance of it is reused for every row, so
+// copy the struct if it must outlive the call to fn.
+//
+// ForEach stops at the end of the input, at the first read error, or when
+// fn returns an error. Returning ErrStop from fn stops early and makes
+// ForEach return nil.
+func (r *CSVReader) ForEach(prototype interface{}, fn func(i int, row interface{}) error) error {
+	protoValue, err := structValue(prototype)
+	if err != nil {
+		return err
+	}
+
+	row := reflect.New(protoValue.Type()).Interface()
+	for i := 0; ; i++ {
+		err := r.ReadNext(row)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(i, row); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+	}
+}
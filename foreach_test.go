@@ -0,0 +1,56 @@
+package gocsv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	content := "string_field,int_field\na,1\nb,2\nc,3\nd,x\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var seen []string
+	var first *TestStruct
+	err = reader.ForEach(TestStruct{}, func(i int, row interface{}) error {
+		ts := row.(*TestStruct)
+		if first == nil {
+			first = ts
+		} else if ts != first {
+			t.Errorf("row %d: expected the struct to be reused", i)
+		}
+		seen = append(seen, ts.StringField)
+		if i == 1 {
+			return ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected nil after ErrStop, got %v", err)
+	}
+	if strings.Join(seen, "") != "ab" {
+		t.Errorf("expected rows a and b before stopping, got %v", seen)
+	}
+
+	errBoom := errors.New("boom")
+	err = reader.ForEach(&TestStruct{}, func(i int, row interface{}) error {
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("expected the callback error, got %v", err)
+	}
+
+	err = reader.ForEach(TestStruct{}, func(i int, row interface{}) error {
+		return nil
+	})
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Line != 5 {
+		t.Errorf("expected a CSVError on line 5, got %v", err)
+	}
+
+	if err := reader.ForEach(42, func(int, interface{}) error { return nil }); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package gocsv
+
+// ReadAllInto reads all remaining records of r into a new slice of T,
+// which must be a struct type
+func ReadAllInto[T any](r *CSVReader) ([]T, error) {
+	var rows []T
+	if err := r.ReadAll(&rows); err != nil {
+		return rows, err
+	}
+	return rows, nil
+}
+
+// ReadOne reads the next record of r into a new T, which must be a struct
+// type. At the end of the input it returns io.EOF.
+func ReadOne[T any](r *CSVReader) (T, error) {
+	var row T
+	err := r.ReadNext(&row)
+	return row, err
+}
@@ -1,3 +1,8 @@
 module github.com/kmohhidayah/gocsv
 
 go 1.21.3
+
+require (
+	github.com/klauspost/compress v1.17.11
+	golang.org/x/text v0.22.0
+)
@@ -0,0 +1,142 @@
+package gocsv
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldGuess is the type InferSchema suggests for one column
+type FieldGuess struct {
+	// Column is the header name
+	Column string
+	// Type is the Go type every sampled value parsed as: "int", "float64",
+	// "bool", "time.Time" or, when nothing narrower fits, "string"
+	Type string
+	// Layout is the time layout that parsed the column when Type is
+	// "time.Time"
+	Layout string
+	// Optional is set when some sampled cells were empty, suggesting a
+	// pointer field
+	Optional bool
+}
+
+// InferSchema reads up to sample records from src, or all of them if
+// sample is not positive, and guesses a Go type for each column from the
+// values that parse. The guesses follow the header order and are meant as
+// a starting point when writing a struct for an unfamiliar file. Options
+// such as WithDelimiter are applied as for NewCSVReaderFromReader; with
+// WithNoHeader columns are named by position, "0", "1" and so on.
+func InferSchema(src io.Reader, sample int, opts ...Option) ([]FieldGuess, error) {
+	reader, err := newCSVReader(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Release the decompressor of WithDecompression, but not src
+	defer reader.closeDecompressor()
+	if sample > 0 {
+		reader.limit = sample
+	}
+
+	candidates := make([]columnCandidates, len(reader.headers))
+	for i := range candidates {
+		candidates[i] = newColumnCandidates()
+	}
+	for {
+		record, err := reader.readRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, reader.withPosition(err)
+		}
+		// Without a header the widest record decides the column count
+		for reader.noHeader && len(candidates) < len(record) {
+			candidates = append(candidates, newColumnCandidates())
+		}
+		for i := range candidates {
+			value := ""
+			if i < len(record) {
+				value = strings.TrimSpace(record[i])
+			}
+			candidates[i].observe(value)
+		}
+	}
+
+	guesses := make([]FieldGuess, len(candidates))
+	for i, c := range candidates {
+		column := strconv.Itoa(i)
+		if !reader.noHeader {
+			column = reader.headers[i]
+		}
+		guesses[i] = c.guess(column)
+	}
+	return guesses, nil
+}
+
+// columnCandidates tracks which types every non-empty value of a column
+// has parsed as so far
+type columnCandidates struct {
+	seen    bool
+	empty   bool
+	isInt   bool
+	isFloat bool
+	isBool  bool
+	layouts []string
+}
+
+func newColumnCandidates() columnCandidates {
+	return columnCandidates{
+		isInt:   true,
+		isFloat: true,
+		isBool:  true,
+		layouts: append([]string(nil), commonTimeLayouts...),
+	}
+}
+
+func (c *columnCandidates) observe(value string) {
+	if value == "" {
+		c.empty = true
+		return
+	}
+	c.seen = true
+
+	if c.isInt {
+		_, err := strconv.ParseInt(value, 10, 64)
+		c.isInt = err == nil
+	}
+	if c.isFloat {
+		_, err := strconv.ParseFloat(value, 64)
+		c.isFloat = err == nil
+	}
+	if c.isBool {
+		_, err := parseBool(value)
+		c.isBool = err == nil
+	}
+	layouts := c.layouts[:0]
+	for _, layout := range c.layouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			layouts = append(layouts, layout)
+		}
+	}
+	c.layouts = layouts
+}
+
+// guess picks the narrowest type that fits; integers are preferred over
+// bools so that 0 and 1 columns stay numeric
+func (c *columnCandidates) guess(column string) FieldGuess {
+	g := FieldGuess{Column: column, Type: "string", Optional: c.empty}
+	switch {
+	case !c.seen:
+	case c.isInt:
+		g.Type = "int"
+	case c.isFloat:
+		g.Type = "float64"
+	case c.isBool:
+		g.Type = "bool"
+	case len(c.layouts) > 0:
+		g.Type, g.Layout = "time.Time", c.layouts[0]
+	}
+	return g
+}
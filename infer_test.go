@@ -0,0 +1,50 @@
+package gocsv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	content := "id,price,active,joined,name,note,flag\n" +
+		"1,9.99,yes,2024-01-31,Alice,,0\n" +
+		"2,10,no,2024-02-29,Bob,,1\n" +
+		"3,,Y,,42,,1\n" +
+		"x,1e3,maybe,31/12/2024,Carol,,z\n"
+
+	guesses, err := InferSchema(strings.NewReader(content), 3)
+	if err != nil {
+		t.Fatalf("InferSchema failed: %v", err)
+	}
+	expected := []FieldGuess{
+		{Column: "id", Type: "int"},
+		{Column: "price", Type: "float64", Optional: true},
+		{Column: "active", Type: "bool"},
+		{Column: "joined", Type: "time.Time", Layout: DateOnly, Optional: true},
+		{Column: "name", Type: "string"},
+		{Column: "note", Type: "string", Optional: true},
+		{Column: "flag", Type: "int"},
+	}
+	if !reflect.DeepEqual(guesses, expected) {
+		t.Errorf("got %+v, want %+v", guesses, expected)
+	}
+
+	// The fourth row only counts when every row is sampled
+	guesses, err = InferSchema(strings.NewReader(content), 0)
+	if err != nil {
+		t.Fatalf("InferSchema failed: %v", err)
+	}
+	if guesses[0].Type != "string" || guesses[1].Type != "float64" || guesses[3].Type != "string" || guesses[6].Type != "string" {
+		t.Errorf("unexpected guesses for the full file: %+v", guesses)
+	}
+
+	guesses, err = InferSchema(strings.NewReader("1;a\n2;b\n"), 0, WithDelimiter(';'), WithNoHeader())
+	if err != nil {
+		t.Fatalf("InferSchema failed: %v", err)
+	}
+	expected = []FieldGuess{{Column: "0", Type: "int"}, {Column: "1", Type: "string"}}
+	if !reflect.DeepEqual(guesses, expected) {
+		t.Errorf("got %+v without a header, want %+v", guesses, expected)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build go1.23
+
+package gocsv
+
+import (
+	"io"
+	"iter"
+)
+
+// All returns an iterator over the remaining records. Each record is
+// decoded into dest before the zero-based row index is yielded, so the
+// caller sees a freshly populated dest on every iteration:
+//
+//	var row Person
+//	for i, err := range reader.All(&row) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(i, row.Name)
+//	}
+//
+// Iteration stops at EOF or after the first error is yielded.
+func (r *CSVReader) All(dest interface{}) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		for i := 0; ; i++ {
+			err := r.ReadNext(dest)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(i, err)
+				return
+			}
+			if !yield(i, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+//go:build go1.23
+
+package gocsv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	content := `string_field,int_field
+value1,1
+value2,2
+value3,3`
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var row TestStruct
+	sum := 0
+	count := 0
+	for i, err := range reader.All(&row) {
+		if err != nil {
+			t.Fatalf("row %d: unexpected error: %v", i, err)
+		}
+		if i != count {
+			t.Errorf("expected index %d, got %d", count, i)
+		}
+		sum += row.IntField
+		count++
+	}
+
+	if count != 3 || sum != 6 {
+		t.Errorf("expected 3 rows summing to 6, got %d rows summing to %d", count, sum)
+	}
+}
+
+func TestAllStopsOnError(t *testing.T) {
+	content := `string_field,int_field
+value1,1
+value2,abc
+value3,3`
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var row TestStruct
+	var lastErr error
+	iterations := 0
+	for _, err := range reader.All(&row) {
+		iterations++
+		lastErr = err
+	}
+
+	if iterations != 2 {
+		t.Errorf("expected 2 iterations, got %d", iterations)
+	}
+	if lastErr == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -0,0 +1,88 @@
+package gocsv
+
+import (
+	"io"
+	"slices"
+	"strconv"
+)
+
+// ReadNextMap reads the next record as a map from header name to cell
+// value, for callers without a struct describing the file. Values are
+// trimmed like struct fields, see SetTrimSpace. Readers without a header
+// use the column position, "0", "1" and so on, as the key. At the end of
+// the input it returns io.EOF.
+func (r *CSVReader) ReadNextMap() (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, err := r.readRecord()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, r.withPosition(err)
+	}
+	return r.recordMap(record), nil
+}
+
+// ReadNextRaw reads the next record as the cells found in the input, with
+// no struct mapping or trimming, for callers doing their own mapping. Pair
+// it with Headers. The returned slice is not reused by later reads. At the
+// end of the input it returns io.EOF.
+func (r *CSVReader) ReadNextRaw() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, err := r.readRecord()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, r.withPosition(err)
+	}
+	// The csv.Reader reuses its record slice, so hand out a copy
+	return slices.Clone(record), nil
+}
+
+// ReadAllMaps reads all remaining records as maps, see ReadNextMap
+func (r *CSVReader) ReadAllMaps() ([]map[string]string, error) {
+	var rows []map[string]string
+	for {
+		row, err := r.ReadNextMap()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// recordMap zips the header with record. Cells beyond the header are
+// dropped and columns missing from a short record or not selected with
+// SelectColumns are left out.
+func (r *CSVReader) recordMap(record []string) map[string]string {
+	if r.noHeader {
+		row := make(map[string]string, len(record))
+		for i, value := range record {
+			if r.selected != nil && !r.selected[i] {
+				continue
+			}
+			row[strconv.Itoa(i)] = r.trim(value, csvTag{})
+		}
+		return row
+	}
+
+	row := make(map[string]string, len(r.headers))
+	for i, header := range r.headers {
+		if i >= len(record) {
+			break
+		}
+		if r.selected != nil && !r.selected[i] {
+			continue
+		}
+		row[header] = r.trim(record[i], csvTag{})
+	}
+	return row
+}
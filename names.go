@@ -0,0 +1,39 @@
+package gocsv
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MatchSnakeCase is a name matcher for SetNameMatcher that pairs a field
+// such as FirstName or UserID with a snake_case header such as first_name
+// or user_id. Case is ignored.
+func MatchSnakeCase(field, header string) bool {
+	return strings.EqualFold(snakeCase(field), header)
+}
+
+// MatchCamelCase is a name matcher for SetNameMatcher that pairs a field
+// such as FirstName with a camelCase header such as firstName. Case is
+// ignored, so UserID also matches userId.
+func MatchCamelCase(field, header string) bool {
+	return strings.EqualFold(field, header)
+}
+
+// snakeCase converts a Go identifier to snake_case, keeping acronyms
+// together: HTTPServer becomes http_server
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+	for i, c := range runes {
+		if i > 0 && unicode.IsUpper(c) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
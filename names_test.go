@@ -0,0 +1,72 @@
+package gocsv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Name":       "name",
+		"FirstName":  "first_name",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"Address2":   "address2",
+		"Line2Text":  "line2_text",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSetNameMatcher(t *testing.T) {
+	type address struct {
+		PostCode string
+	}
+	type person struct {
+		FirstName string
+		UserID    int
+		Email     string `csv:"contact"`
+		Address   address
+		Note      string
+	}
+
+	content := "first_name,user_id,contact,Address.post_code,note,Note\n" +
+		"Alice,7,a@example.com,12345,lower,exact\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetNameMatcher(MatchSnakeCase)
+
+	var got person
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := person{FirstName: "Alice", UserID: 7, Email: "a@example.com", Address: address{PostCode: "12345"}, Note: "exact"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("firstName,userId\nBob,8\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetNameMatcher(MatchCamelCase)
+	got = person{}
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.FirstName != "Bob" || got.UserID != 8 {
+		t.Errorf("unexpected camelCase match: %+v", got)
+	}
+
+	if !MatchSnakeCase("FirstName", "FIRST_NAME") || MatchSnakeCase("FirstName", "firstname") {
+		t.Error("unexpected MatchSnakeCase result")
+	}
+	if !MatchCamelCase("FirstName", "firstName") || MatchCamelCase("FirstName", "first_name") {
+		t.Error("unexpected MatchCamelCase result")
+	}
+}
@@ -0,0 +1,131 @@
+package gocsv
+
+import (
+	"strings"
+)
+
+// defaultCurrencySymbol is stripped and written by a bare currency tag
+// option; currency=€ chooses another symbol
+const defaultCurrencySymbol = "$"
+
+// unformatNumber removes the decoration of percent, currency and
+// accounting cells, so "45%" becomes "45", "$1,234.50" becomes "1234.50"
+// and "(1,234.50)" becomes "-1234.50". Currency and accounting cells drop
+// the configured thousands separator, or ',' when none is set and the
+// decimal separator is not a comma.
+func (r *CSVReader) unformatNumber(value string, tag csvTag) string {
+	if tag.percent {
+		value = strings.TrimSpace(strings.TrimSuffix(value, "%"))
+	}
+	if tag.currency != "" {
+		value = strings.TrimSpace(strings.Replace(value, tag.currency, "", 1))
+	}
+	if tag.accounting && strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value = "-" + strings.TrimSpace(value[1:len(value)-1])
+	}
+	if tag.currency != "" || tag.accounting {
+		sep := r.groupSep
+		if sep == 0 && r.decimalSep != ',' {
+			sep = ','
+		}
+		if sep != 0 {
+			value = strings.ReplaceAll(value, string(sep), "")
+		}
+	}
+	return value
+}
+
+// formatNumber decorates a formatted number for percent, currency and
+// accounting fields, the inverse of unformatNumber. Currency and
+// accounting values are grouped by thousands, floats are written with at
+// least two decimals, and accounting negatives are put in parentheses.
+func formatNumber(text string, tag csvTag, isFloat bool) string {
+	if tag.percent {
+		return text + "%"
+	}
+	if tag.currency == "" && !tag.accounting {
+		return text
+	}
+
+	negative := strings.HasPrefix(text, "-")
+	if negative {
+		text = text[1:]
+	}
+	whole, frac, _ := strings.Cut(text, ".")
+	if !isDecimalDigits(whole) {
+		// Leave Inf, NaN and non-decimal bases alone
+		if negative {
+			text = "-" + text
+		}
+		return tag.currency + text
+	}
+	if strings.Trim(whole+frac, "0") == "" {
+		// Negative zero is written as zero
+		negative = false
+	}
+
+	var b strings.Builder
+	b.WriteString(tag.currency)
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	if isFloat {
+		for len(frac) < 2 {
+			frac += "0"
+		}
+		b.WriteByte('.')
+		b.WriteString(frac)
+	}
+
+	switch {
+	case negative && tag.accounting:
+		return "(" + b.String() + ")"
+	case negative:
+		return "-" + b.String()
+	}
+	return b.String()
+}
+
+// shiftDecimal moves the decimal point of a plain decimal number such as
+// "-12.5" by places, scaling it by a power of ten without the rounding
+// error of multiplying or dividing a float. It reports false for other
+// text, such as exponents, "Inf" or "NaN".
+func shiftDecimal(text string, places int) (string, bool) {
+	sign := ""
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		sign, text = text[:1], text[1:]
+	}
+	whole, frac, _ := strings.Cut(text, ".")
+	if whole+frac == "" || !isDecimalDigits(whole) || !isDecimalDigits(frac) {
+		return "", false
+	}
+
+	digits, point := whole+frac, len(whole)+places
+	for point < 0 {
+		digits, point = "0"+digits, point+1
+	}
+	for point > len(digits) {
+		digits += "0"
+	}
+	whole = strings.TrimLeft(digits[:point], "0")
+	frac = strings.TrimRight(digits[point:], "0")
+	if whole == "" {
+		whole = "0"
+	}
+	if frac == "" {
+		return sign + whole, true
+	}
+	return sign + whole + "." + frac, true
+}
+
+func isDecimalDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package gocsv
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+type pricedRow struct {
+	Name     string  `csv:"name"`
+	Discount float64 `csv:"discount,percent"`
+	Price    float64 `csv:"price,currency"`
+	Stock    int     `csv:"stock,currency=€"`
+	Share    int     `csv:"share,percent"`
+}
+
+func TestPercentAndCurrency(t *testing.T) {
+	content := "name;discount;price;stock;share\n" +
+		"a;45%;$1,234.50;€2,000;7%\n" +
+		"b; 7 % ;-$0.99;€5;100\n" +
+		"c;4.35%;$12;€0;0%\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content), WithDelimiter(';'))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var rows []pricedRow
+	if err := reader.ReadAll(&rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []pricedRow{
+		{"a", 0.45, 1234.5, 2000, 7},
+		{"b", 0.07, -0.99, 5, 100},
+		{"c", 0.0435, 12, 0, 0},
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d: got %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("unexpected flush error: %v", err)
+	}
+	wantCSV := "name,discount,price,stock,share\n" +
+		"a,45%,\"$1,234.50\",\"€2,000\",7%\n" +
+		"b,7%,-$0.99,€5,100%\n" +
+		"c,4.35%,$12.00,€0,0%\n"
+	if buf.String() != wantCSV {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), wantCSV)
+	}
+}
+
+func TestCurrencyWithDecimalComma(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("price\n\"€1.234,50\"\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetDecimalSeparator(','); err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.SetThousandsSeparator('.'); err != nil {
+		t.Fatal(err)
+	}
+
+	var row struct {
+		Price float64 `csv:"price,currency=€"`
+	}
+	if err := reader.ReadNext(&row); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if row.Price != 1234.5 {
+		t.Errorf("got %v, want 1234.5", row.Price)
+	}
+}
+
+func TestShiftDecimal(t *testing.T) {
+	tests := []struct {
+		text   string
+		places int
+		want   string
+		ok     bool
+	}{
+		{"45", -2, "0.45", true},
+		{"4.35", -2, "0.0435", true},
+		{"-7", -2, "-0.07", true},
+		{"0.0435", 2, "4.35", true},
+		{"12", 2, "1200", true},
+		{"1e3", -2, "", false},
+		{"NaN", 2, "", false},
+		{"", -2, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := shiftDecimal(tt.text, tt.places)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("shiftDecimal(%q, %d) = %q, %v, want %q, %v", tt.text, tt.places, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAccounting(t *testing.T) {
+	type entry struct {
+		Amount  float64 `csv:"amount,accounting"`
+		Balance int     `csv:"balance,accounting,currency"`
+	}
+
+	content := "amount;balance\n" +
+		"(1,234.50);($2,000)\n" +
+		"(0.00);$(5)\n" +
+		"12.5;$0\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content), WithDelimiter(';'))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var rows []entry
+	if err := reader.ReadAll(&rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []entry{{-1234.5, -2000}, {0, -5}, {12.5, 0}}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d: got %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+	if math.Signbit(rows[1].Amount) {
+		t.Error("expected (0.00) to read as positive zero")
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("unexpected flush error: %v", err)
+	}
+	wantCSV := "amount,balance\n" +
+		"\"(1,234.50)\",\"($2,000)\"\n" +
+		"0.00,($5)\n" +
+		"12.50,$0\n"
+	if buf.String() != wantCSV {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), wantCSV)
+	}
+
+	var bad entry
+	reader, err = NewCSVReaderFromReader(strings.NewReader("amount\n(12\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.ReadNext(&bad); err == nil {
+		t.Error("expected an unbalanced parenthesis to be rejected")
+	}
+}
@@ -0,0 +1,135 @@
+package gocsv
+
+import (
+	"fmt"
+	"strconv"
+
+	"golang.org/x/text/encoding"
+)
+
+// options holds configuration that must be applied before the header
+// row is read
+type options struct {
+	delimiter   rune
+	comment     rune
+	quote       rune
+	escape      rune
+	noHeader    bool
+	lazy        bool
+	skipRows    int
+	encoding    encoding.Encoding
+	timeLayout  string
+	bufferSize  int
+	compression Compression
+}
+
+func defaultOptions() options {
+	return options{
+		delimiter:  ',',
+		timeLayout: DateOnly, // Default layout
+	}
+}
+
+// Option configures a CSVReader at construction time
+type Option func(*options) error
+
+// WithDelimiter sets the field delimiter used for the header and all records
+func WithDelimiter(delim rune) Option {
+	return func(o *options) error {
+		if err := validateDelimiter(delim); err != nil {
+			return err
+		}
+		o.delimiter = delim
+		return nil
+	}
+}
+
+// WithComment skips lines that start with comment, including any that
+// precede the header row. A zero rune disables comments.
+func WithComment(comment rune) Option {
+	return func(o *options) error {
+		if err := validateComment(comment); err != nil {
+			return err
+		}
+		o.comment = comment
+		return nil
+	}
+}
+
+// WithLazyQuotes tolerates bare quotes in unquoted fields and unescaped
+// quotes in quoted fields, including in the header row
+func WithLazyQuotes() Option {
+	return func(o *options) error {
+		o.lazy = true
+		return nil
+	}
+}
+
+// WithTimeLayout sets the default layout used to parse time.Time fields
+// that have no format in their csv tag
+func WithTimeLayout(layout string) Option {
+	return func(o *options) error {
+		if err := validateTimeLayout(layout); err != nil {
+			return &CSVError{
+				Field:   "timeLayout",
+				Value:   layout,
+				Type:    "string",
+				Wrapped: err,
+			}
+		}
+		o.timeLayout = layout
+		return nil
+	}
+}
+
+// WithBufferSize sets the size in bytes of the read buffer placed between
+// the input and the CSV parser. Larger buffers mean fewer reads from the
+// underlying file on large inputs. The default is 4096.
+func WithBufferSize(n int) Option {
+	return func(o *options) error {
+		if err := validateBufferSize(n); err != nil {
+			return err
+		}
+		o.bufferSize = n
+		return nil
+	}
+}
+
+func validateBufferSize(n int) error {
+	if n <= 0 {
+		return &CSVError{
+			Field:   "bufferSize",
+			Value:   strconv.Itoa(n),
+			Type:    "int",
+			Wrapped: fmt.Errorf("buffer size must be positive"),
+		}
+	}
+	return nil
+}
+
+// WithNoHeader treats the first row as data instead of a header. Struct
+// fields are then mapped by column position using index tags such as
+// csv:"[0]" or csv:"0".
+func WithNoHeader() Option {
+	return func(o *options) error {
+		o.noHeader = true
+		return nil
+	}
+}
+
+// WithSkipRows discards the first n physical lines, such as a title or
+// metadata block, before the header row is read
+func WithSkipRows(n int) Option {
+	return func(o *options) error {
+		if n < 0 {
+			return &CSVError{
+				Field:   "skipRows",
+				Value:   strconv.Itoa(n),
+				Type:    "int",
+				Wrapped: fmt.Errorf("skip rows cannot be negative"),
+			}
+		}
+		o.skipRows = n
+		return nil
+	}
+}
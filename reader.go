@@ -1,54 +1,533 @@
 package gocsv
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding"
+	"encoding/base64"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"math/big"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// CSVUnmarshaler is implemented by types that can decode themselves from a
+// single CSV cell
+type CSVUnmarshaler interface {
+	UnmarshalCSV(value string) error
+}
+
+var (
+	csvUnmarshalerType  = reflect.TypeOf((*CSVUnmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+const (
+	utf8BOM = "\ufeff"
+
+	// DefaultSliceSeparator separates the elements of slice fields
+	DefaultSliceSeparator = ";"
+
+	// defaultBufferSize matches the buffer csv.Reader would otherwise use
+	defaultBufferSize = 4096
 )
 
 type CSVReader struct {
-	reader     *csv.Reader
-	file       *os.File
-	headers    []string
-	headerMap  map[string]int
-	timeLayout string
-	mu         sync.RWMutex
+	reader       *csv.Reader
+	file         *os.File
+	closers      []io.Closer
+	decompressor io.Closer
+	rewind       rewindFunc
+	opts         options
+	headers      []string
+	headerMap    map[string]int
+	noHeader     bool
+	foldCase     bool
+	rows         int
+	line         int
+	lineOffset   int
+	timeLayout   string
+	timeLayouts  []string
+	strictTime   bool
+	sciInts      bool
+	location     *time.Location
+	boolValues   map[string]bool
+	decimalSep   rune
+	groupSep     rune
+	sliceSep     string
+	nonEmpty     map[string]bool
+	converters   map[string]func(string) (interface{}, error)
+	nullValues   map[string]bool
+	variable     bool
+	limit        int
+	strict       bool
+	mapping      map[string]string
+	nameMatcher  func(field, header string) bool
+	aliases      map[string][]string
+	selected     map[int]bool
+	noTrim       bool
+	warn         func(Warning)
+	warned       map[reflect.Type]bool // types whose unmapped headers were reported
+	plans        map[reflect.Type]*structPlan
+
+	// The state of Scan
+	scanRecord []string
+	scanErr    error
+
+	// The fallback layout that last parsed a time value, and whether it
+	// is one of commonTimeLayouts
+	detectedLayout string
+	detectedCommon bool
+
+	mu sync.RWMutex
 }
 
 // NewCSVReader creates a new CSV reader with the specified file path
 func NewCSVReader(filePath string) (*CSVReader, error) {
+	return NewCSVReaderWithOptions(filePath)
+}
+
+// NewCSVReaderWithOptions creates a new CSV reader with the specified file
+// path, applying opts before the header row is read
+func NewCSVReaderWithOptions(filePath string, opts ...Option) (*CSVReader, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, &CSVError{Field: "file", Value: filePath, Wrapped: err}
 	}
 
-	reader := csv.NewReader(file)
-	headers, err := reader.Read()
+	r, err := newCSVReader(file, opts)
 	if err != nil {
 		file.Close()
-		return nil, &CSVError{Field: "headers", Wrapped: err}
+		return nil, err
 	}
+	r.file = file
+	r.rewind = seekTo(file, 0)
 
-	// Initialize header map
-	headerMap := make(map[string]int, len(headers))
-	for i, header := range headers {
-		headerMap[header] = i
+	return r, nil
+}
+
+// NewCSVReaderNoHeader creates a new CSV reader for a file without a header
+// row. Fields are mapped by column position, see WithNoHeader.
+func NewCSVReaderNoHeader(filePath string) (*CSVReader, error) {
+	return NewCSVReaderWithOptions(filePath, WithNoHeader())
+}
+
+// NewCSVReaderGzip creates a new CSV reader for a gzip-compressed file such
+// as an archived .csv.gz export. Close releases both the gzip reader and
+// the file.
+func NewCSVReaderGzip(filePath string, opts ...Option) (*CSVReader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, &CSVError{Field: "file", Value: filePath, Wrapped: err}
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, &CSVError{Field: "file", Value: filePath, Type: "gzip", Wrapped: err}
+	}
+	g := &gzipFile{file: file, Reader: gz}
+
+	r, err := newCSVReader(gz, opts)
+	if err != nil {
+		gz.Close()
+		file.Close()
+		return nil, err
 	}
+	r.file = file
+	r.closers = append(r.closers, g)
+	r.rewind = g.reopen
 
-	return &CSVReader{
-		reader:     reader,
-		file:       file,
-		headers:    headers,
-		headerMap:  headerMap,
-		timeLayout: DateOnly, // Default layout
+	return r, nil
+}
+
+// gzipFile holds the gzip reader of a file and can start over with a new
+// one, leaving the current reader untouched until the reopen succeeds
+type gzipFile struct {
+	file *os.File
+	*gzip.Reader
+}
+
+func (g *gzipFile) reopen() (io.Reader, func(bool), error) {
+	// ReadAt leaves the offset the current reader depends on alone
+	fresh, err := gzip.NewReader(io.NewSectionReader(g.file, 0, math.MaxInt64))
+	if err != nil {
+		return nil, nil, err
+	}
+	return fresh, func(ok bool) {
+		if !ok {
+			fresh.Close()
+			return
+		}
+		g.Reader.Close()
+		g.Reader = fresh
+	}, nil
+}
+
+// NewCSVReaderFromZip creates a new CSV reader for the file memberName
+// inside the zip archive at zipPath, without extracting it. Close releases
+// both the member and the archive.
+func NewCSVReaderFromZip(zipPath, memberName string, opts ...Option) (*CSVReader, error) {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, &CSVError{Field: "file", Value: zipPath, Type: "zip", Wrapped: err}
+	}
+
+	var member *zip.File
+	for _, f := range archive.File {
+		if f.Name == memberName {
+			member = f
+			break
+		}
+	}
+	if member == nil {
+		archive.Close()
+		return nil, &CSVError{Field: "file", Value: memberName, Type: "zip", Wrapped: fs.ErrNotExist}
+	}
+
+	src, err := member.Open()
+	if err != nil {
+		archive.Close()
+		return nil, &CSVError{Field: "file", Value: memberName, Type: "zip", Wrapped: err}
+	}
+	m := &zipMember{file: member, ReadCloser: src}
+
+	r, err := newCSVReader(m, opts)
+	if err != nil {
+		m.Close()
+		archive.Close()
+		return nil, err
+	}
+	r.closers = append(r.closers, archive, m)
+	r.rewind = m.reopen
+
+	return r, nil
+}
+
+// zipMember reads a zip archive member and can start over by reopening it
+type zipMember struct {
+	file *zip.File
+	io.ReadCloser
+}
+
+func (m *zipMember) reopen() (io.Reader, func(bool), error) {
+	src, err := m.file.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	return src, func(ok bool) {
+		if !ok {
+			src.Close()
+			return
+		}
+		m.ReadCloser.Close()
+		m.ReadCloser = src
 	}, nil
 }
 
+// NewCSVReaderFromReader creates a new CSV reader that reads from src.
+// If src implements io.Closer it is closed by Close, and if it implements
+// io.Seeker the reader supports Reset, which returns to the offset src
+// was at when the reader was created.
+func NewCSVReaderFromReader(src io.Reader, opts ...Option) (*CSVReader, error) {
+	// The CSV need not start at the beginning of src, as when a caller
+	// has already read past a preamble, so remember where it does
+	seeker, seekable := src.(io.ReadSeeker)
+	var start int64
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			// Pipes such as os.Stdin implement Seek but cannot seek
+			seekable = false
+		}
+	}
+
+	r, err := newCSVReader(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := src.(io.Closer); ok {
+		r.closers = append(r.closers, closer)
+	}
+	if seekable {
+		r.rewind = seekTo(seeker, start)
+	}
+
+	return r, nil
+}
+
+// NewCSVReaderBytes creates a new CSV reader for data already held in
+// memory, such as an uploaded file. The reader supports Reset, and Close
+// is a no-op.
+func NewCSVReaderBytes(data []byte, opts ...Option) (*CSVReader, error) {
+	return NewCSVReaderFromReader(bytes.NewReader(data), opts...)
+}
+
+func newCSVReader(src io.Reader, opts []Option) (*CSVReader, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &CSVReader{
+		opts:       o,
+		noHeader:   o.noHeader,
+		timeLayout: o.timeLayout,
+		location:   time.UTC,
+		sliceSep:   DefaultSliceSeparator,
+	}
+	if err := r.open(src); err != nil {
+		r.closeDecompressor()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// open builds the csv.Reader for src and reads the header row, applying
+// the options given at construction
+func (r *CSVReader) open(src io.Reader) error {
+	if r.opts.compression != CompressionNone {
+		decompressed, err := r.decompress(src)
+		if err != nil {
+			return &CSVError{Field: "decompression", Type: r.opts.compression.String(), Wrapped: err}
+		}
+		src = decompressed
+	}
+	if r.opts.encoding != nil {
+		src = transform.NewReader(src, r.opts.encoding.NewDecoder())
+	}
+
+	bufferSize := defaultBufferSize
+	if r.opts.bufferSize > 0 {
+		bufferSize = r.opts.bufferSize
+	}
+	buffered := bufio.NewReaderSize(src, bufferSize)
+
+	// Strip the UTF-8 byte order mark written by tools such as Excel,
+	// otherwise it ends up in the first header name
+	if bom, err := buffered.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		buffered.Discard(len(utf8BOM))
+	}
+
+	for i := 0; i < r.opts.skipRows; i++ {
+		_, err := buffered.ReadString('\n')
+		if err == io.EOF {
+			// Nothing is left, so the header read reports ErrEmptyFile
+			break
+		}
+		if err != nil {
+			return &CSVError{Field: "skipRows", Wrapped: err}
+		}
+	}
+
+	// csv.NewReader reuses buffered, so the skipped lines stay consumed
+	var input io.Reader = buffered
+	if r.opts.needsQuoteRewrite() {
+		input = newQuoteReader(buffered, &r.opts)
+	}
+	reader := csv.NewReader(input)
+	reader.Comma = r.opts.delimiter
+	reader.Comment = r.opts.comment
+	reader.LazyQuotes = r.opts.lazy
+	// Records never outlive a single read, so their slice can be reused
+	reader.ReuseRecord = true
+	if r.variable {
+		reader.FieldsPerRecord = -1
+	}
+
+	r.reader = reader
+	r.lineOffset = r.opts.skipRows
+	r.rows = 0
+	r.line = 0
+	r.plans = nil
+	r.scanRecord, r.scanErr = nil, nil
+	if r.noHeader {
+		return nil
+	}
+
+	headers, err := reader.Read()
+	if err == io.EOF {
+		return &CSVError{Field: "headers", Wrapped: ErrEmptyFile}
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &CSVError{
+			Field:   "headers",
+			Line:    parseErr.Line + r.lineOffset,
+			Column:  parseErr.Column,
+			Wrapped: parseErr.Err,
+		}
+	}
+	if err != nil {
+		return &CSVError{Field: "headers", Wrapped: err}
+	}
+	// The record slice is reused by the next read, so keep a copy
+	headers = slices.Clone(headers)
+
+	headerMap, err := buildHeaderMap(headers, r.foldCase)
+	if err != nil {
+		return err
+	}
+	r.headers = headers
+	r.headerMap = headerMap
+
+	return nil
+}
+
+// Reset rewinds the reader to the first record so the data can be read
+// again. The header is re-read and must match the one read originally;
+// if it does not, or cannot be read, the reader keeps its previous header
+// and position. Readers created from a source that cannot seek return
+// ErrNotSeekable.
+func (r *CSVReader) Reset() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.rewind == nil {
+		return ErrNotSeekable
+	}
+	src, finish, err := r.rewind()
+	if err != nil {
+		return &CSVError{Field: "reset", Wrapped: err}
+	}
+
+	prev := r.saveState()
+	err = r.open(src)
+	if err == nil && !slices.Equal(prev.headers, r.headers) {
+		err = &CSVError{
+			Field:   "headers",
+			Value:   strings.Join(r.headers, ","),
+			Wrapped: fmt.Errorf("header changed since the reader was created"),
+		}
+	}
+	if err != nil {
+		r.closeDecompressor()
+		r.restoreState(prev)
+		finish(false)
+		return err
+	}
+
+	finish(true)
+	if prev.decompressor != nil {
+		prev.decompressor.Close()
+	}
+	return nil
+}
+
+// readerState is the part of a reader that open replaces, which Reset
+// puts back when reopening fails
+type readerState struct {
+	reader       *csv.Reader
+	decompressor io.Closer
+	headers      []string
+	headerMap    map[string]int
+	lineOffset   int
+	rows         int
+	line         int
+	plans        map[reflect.Type]*structPlan
+	scanRecord   []string
+	scanErr      error
+}
+
+func (r *CSVReader) saveState() readerState {
+	return readerState{
+		reader:       r.reader,
+		decompressor: r.decompressor,
+		headers:      r.headers,
+		headerMap:    r.headerMap,
+		lineOffset:   r.lineOffset,
+		rows:         r.rows,
+		line:         r.line,
+		plans:        r.plans,
+		scanRecord:   r.scanRecord,
+		scanErr:      r.scanErr,
+	}
+}
+
+func (r *CSVReader) restoreState(s readerState) {
+	r.reader = s.reader
+	r.decompressor = s.decompressor
+	r.headers = s.headers
+	r.headerMap = s.headerMap
+	r.lineOffset = s.lineOffset
+	r.rows = s.rows
+	r.line = s.line
+	r.plans = s.plans
+	r.scanRecord = s.scanRecord
+	r.scanErr = s.scanErr
+}
+
+// Count reads the remaining records and returns how many there are,
+// excluding the header. Records are not decoded, but the whole remaining
+// input is still read and parsed, so on large files this costs about as
+// much I/O as a full pass. The reader is left at EOF; call Reset before
+// reading the records.
+func (r *CSVReader) Count() (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Ragged rows still count as rows
+	fieldsPerRecord := r.reader.FieldsPerRecord
+	r.reader.FieldsPerRecord = -1
+	defer func() { r.reader.FieldsPerRecord = fieldsPerRecord }()
+
+	count := 0
+	for {
+		_, err := r.readRecord()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, r.withPosition(err)
+		}
+		count++
+	}
+}
+
+// rewindFunc returns a source starting over at the beginning of the CSV,
+// and a function Reset calls with whether reopening it succeeded. On
+// failure the source the reader had must be left as it was.
+type rewindFunc func() (src io.Reader, finish func(ok bool), err error)
+
+// seekTo returns a rewind function that seeks s back to offset, and on
+// failure to where s was before
+func seekTo(s io.ReadSeeker, offset int64) rewindFunc {
+	return func() (io.Reader, func(bool), error) {
+		pos, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, err := s.Seek(offset, io.SeekStart); err != nil {
+			return nil, nil, err
+		}
+		return s, func(ok bool) {
+			if !ok {
+				s.Seek(pos, io.SeekStart)
+			}
+		}, nil
+	}
+}
+
 func (r *CSVReader) SetTimeLayout(layout string) error {
 	if err := r.ValidateTimeLayout(layout); err != nil {
 		return &CSVError{
@@ -60,138 +539,1719 @@ func (r *CSVReader) SetTimeLayout(layout string) error {
 	}
 	r.mu.Lock()
 	r.timeLayout = layout
+	r.detectedLayout = ""
+	r.plans = nil
 	r.mu.Unlock()
 	return nil
 }
 
-// ValidateTimeLayout validates the time layout format
-func (r *CSVReader) ValidateTimeLayout(layout string) error {
-	if layout == "" {
-		return fmt.Errorf("time layout cannot be empty")
+// Headers returns a copy of the header row, or nil for readers without a
+// header
+func (r *CSVReader) Headers() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.headers)
+}
+
+// RowIndex returns the number of data rows read so far, excluding the
+// header and skipped rows, so after a successful ReadNext it is the
+// one-based index of the row just decoded. Rows that failed to parse or
+// decode are counted too, matching the Row of a CSVError. Reset starts
+// counting again.
+func (r *CSVReader) RowIndex() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rows
+}
+
+// HasColumn reports whether the header contains a column called name,
+// honoring SetCaseInsensitiveHeaders
+func (r *CSVReader) HasColumn(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.noHeader {
+		return false
 	}
+	_, ok := r.columnIndex(name)
+	return ok
+}
 
-	// Verify that layout contains at least year, month, and day components
-	hasYear := strings.Contains(layout, "2006")
-	hasMonth := strings.Contains(layout, "01") || strings.Contains(layout, "Jan")
-	hasDay := strings.Contains(layout, "02")
+// SetTimeLayouts registers an ordered list of fallback layouts tried when a
+// time value does not match the field's layout. The first layout that
+// parses the value wins, so feeds mixing formats in one column can be read.
+func (r *CSVReader) SetTimeLayouts(layouts ...string) error {
+	for _, layout := range layouts {
+		if err := r.ValidateTimeLayout(layout); err != nil {
+			return &CSVError{
+				Field:   "timeLayouts",
+				Value:   layout,
+				Type:    "string",
+				Wrapped: err,
+			}
+		}
+	}
+	r.mu.Lock()
+	r.timeLayouts = append([]string(nil), layouts...)
+	r.detectedLayout = ""
+	r.mu.Unlock()
+	return nil
+}
 
-	if !hasYear || !hasMonth || !hasDay {
-		return fmt.Errorf("invalid time layout: must contain at least year, month, and day components")
+// SetStrictTime controls whether a time value that does not match the
+// field's layout is an error. By default the layouts set with
+// SetTimeLayouts and a list of common layouts are tried as a fallback,
+// which can silently accept a malformed or ambiguous date; strict mode
+// turns that fallback off.
+func (r *CSVReader) SetStrictTime(strict bool) {
+	r.mu.Lock()
+	r.strictTime = strict
+	r.mu.Unlock()
+}
+
+// SetAcceptScientificInts lets int and uint fields accept cells written in
+// scientific notation, such as the "1.23E+08" spreadsheets export for
+// long IDs, when they hold a whole number. It is off by default because the
+// value passes through a float64, which only represents integers exactly
+// up to 2^53; larger values are rejected rather than silently rounded.
+func (r *CSVReader) SetAcceptScientificInts(enabled bool) {
+	r.mu.Lock()
+	r.sciInts = enabled
+	r.mu.Unlock()
+}
+
+// SetLocation sets the location used for time values whose layout carries
+// no zone information. Values that include a zone or offset keep it.
+// A nil location resets it to UTC, the default.
+func (r *CSVReader) SetLocation(loc *time.Location) {
+	if loc == nil {
+		loc = time.UTC
 	}
+	r.mu.Lock()
+	r.location = loc
+	r.mu.Unlock()
+}
 
-	// Reference time used by Go for time formatting
-	referenceTime := time.Date(2006, time.January, 02, 15, 04, 05, 0, time.UTC)
-	formatted := referenceTime.Format(layout)
+// SetBoolValues replaces the tokens accepted for bool fields. Matching is
+// case-insensitive. Passing empty lists restores the defaults
+// (true/1/yes/y and false/0/no/n).
+func (r *CSVReader) SetBoolValues(trueVals, falseVals []string) error {
+	var boolValues map[string]bool
+	if len(trueVals) > 0 || len(falseVals) > 0 {
+		boolValues = make(map[string]bool, len(trueVals)+len(falseVals))
+		for _, v := range trueVals {
+			boolValues[strings.ToLower(v)] = true
+		}
+		for _, v := range falseVals {
+			key := strings.ToLower(v)
+			if _, exists := boolValues[key]; exists {
+				return &CSVError{
+					Field:   "boolValues",
+					Value:   v,
+					Type:    "bool",
+					Wrapped: fmt.Errorf("value %q is both true and false", v),
+				}
+			}
+			boolValues[key] = false
+		}
+	}
 
-	// Try to parse the formatted date using the provided layout
-	parsedTime, err := time.Parse(layout, formatted)
-	if err != nil {
-		return fmt.Errorf("invalid time layout %s: %v", layout, err)
+	r.mu.Lock()
+	r.boolValues = boolValues
+	r.mu.Unlock()
+	return nil
+}
+
+// SetDecimalSeparator sets the rune used as decimal point in float cells,
+// e.g. ',' for values written as 45,67. When the delimiter is also a comma
+// such cells must be quoted, so files using a decimal comma are usually
+// read together with WithDelimiter(';').
+func (r *CSVReader) SetDecimalSeparator(sep rune) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if sep != 0 && sep == r.groupSep {
+		return &CSVError{
+			Field:   "decimalSeparator",
+			Value:   string(sep),
+			Type:    "rune",
+			Wrapped: fmt.Errorf("decimal separator %q equals thousands separator", sep),
+		}
 	}
+	r.decimalSep = sep
+	return nil
+}
 
-	// Additional validation: ensure the parsed time matches the reference time
-	// This helps catch cases where the layout might parse successfully but lose information
-	expectedFormatted := parsedTime.Format(layout)
-	if formatted != expectedFormatted {
-		return fmt.Errorf("invalid time layout: inconsistent parsing results")
+// SetThousandsSeparator sets the digit grouping rune stripped from int,
+// uint and float cells before parsing, e.g. ',' for 1,234,567 or '.' for
+// 1.234,56. Grouping is off by default so that cells such as "1,2" are not
+// silently read as 12.
+func (r *CSVReader) SetThousandsSeparator(sep rune) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if sep != 0 && sep == r.decimalSep {
+		return &CSVError{
+			Field:   "thousandsSeparator",
+			Value:   string(sep),
+			Type:    "rune",
+			Wrapped: fmt.Errorf("thousands separator %q equals decimal separator", sep),
+		}
+	}
+	r.groupSep = sep
+	return nil
+}
+
+// SetSliceSeparator sets the separator used to split a cell into the
+// elements of a slice field, e.g. "go;csv;parser" for a []string.
+// The default is DefaultSliceSeparator.
+func (r *CSVReader) SetSliceSeparator(sep string) error {
+	if sep == "" {
+		return &CSVError{
+			Field:   "sliceSeparator",
+			Type:    "string",
+			Wrapped: fmt.Errorf("slice separator cannot be empty"),
+		}
+	}
+	r.mu.Lock()
+	r.sliceSep = sep
+	r.mu.Unlock()
+	return nil
+}
+
+// SetEmptyAsError makes empty cells in the named columns an error instead
+// of leaving the field at its zero value. Columns are named as in the csv
+// tags, including any nested prefix such as "address.zip".
+//
+// This only concerns columns present in the header: an absent column never
+// touches the field and is not reported here, use the required tag modifier
+// with ValidateHeaders for that. Fields tagged required are always checked
+// this way as well. A default tag is applied before this check, so a
+// column with a default never fails. Calling it again replaces the
+// previous list.
+func (r *CSVReader) SetEmptyAsError(columns ...string) {
+	nonEmpty := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		nonEmpty[column] = true
+	}
+
+	r.mu.Lock()
+	r.nonEmpty = nonEmpty
+	r.plans = nil
+	r.mu.Unlock()
+}
+
+// SetLimit stops reading after n data rows, after which ReadNext returns
+// io.EOF even if the input continues. Rows already read count towards the
+// limit, and Reset starts counting again. Zero removes the limit.
+func (r *CSVReader) SetLimit(n int) error {
+	if n < 0 {
+		return &CSVError{
+			Field:   "limit",
+			Value:   strconv.Itoa(n),
+			Type:    "int",
+			Wrapped: fmt.Errorf("limit cannot be negative"),
+		}
+	}
+	r.mu.Lock()
+	r.limit = n
+	r.mu.Unlock()
+	return nil
+}
+
+// StrictColumns makes reads fail when any field of the destination struct,
+// other than those tagged csv:"-", has no matching column. Unlike the
+// required tag and ValidateHeaders, which only check selected fields, this
+// rejects partial population of the struct as a whole.
+func (r *CSVReader) StrictColumns(enabled bool) {
+	r.mu.Lock()
+	r.strict = enabled
+	r.plans = nil
+	r.mu.Unlock()
+}
+
+// SetColumnMapping overrides the column names given by csv tags. Keys are
+// struct field names and values are header names, so the same struct can
+// be read from files with different headers. The mapping applies to fields
+// with that name at any depth; for a nested struct field it replaces the
+// column prefix. A nil mapping restores the tags.
+func (r *CSVReader) SetColumnMapping(mapping map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.plans = nil
+	if mapping == nil {
+		r.mapping = nil
+		return
+	}
+	r.mapping = make(map[string]string, len(mapping))
+	for field, column := range mapping {
+		r.mapping[field] = column
+	}
+}
+
+// SetNameMatcher pairs fields that have no column name in their csv tag
+// with a header for which match(fieldName, header) is true, so structs
+// need no tags when the header follows a naming convention. A header equal
+// to the field name is still preferred. MatchSnakeCase and MatchCamelCase
+// cover the usual conventions; a nil match restores exact matching.
+func (r *CSVReader) SetNameMatcher(match func(field, header string) bool) {
+	r.mu.Lock()
+	r.nameMatcher = match
+	r.plans = nil
+	r.mu.Unlock()
+}
+
+// SelectColumns restricts decoding to the named columns, for wide files
+// where only a few are needed. Fields mapped to any other column are
+// skipped as if the column were absent and ReadNextMap only returns the
+// selected columns. It returns a CSVError wrapping ErrColumnMissing if a
+// name is not in the header. Calling it without names selects every
+// column again.
+func (r *CSVReader) SelectColumns(names ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(names) == 0 {
+		r.selected = nil
+		r.plans = nil
+		return nil
+	}
+
+	selected := make(map[int]bool, len(names))
+	var missing []string
+	for _, name := range names {
+		index, ok := r.columnIndex(name)
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		selected[index] = true
+	}
+	if len(missing) > 0 {
+		return &CSVError{
+			Field:   "headers",
+			Value:   strings.Join(missing, ","),
+			Type:    "selected",
+			Wrapped: fmt.Errorf("%w: %s", ErrColumnMissing, strings.Join(missing, ", ")),
+		}
+	}
+	r.selected = selected
+	r.plans = nil
+	return nil
+}
+
+// SetTrimSpace controls whether leading and trailing whitespace is removed
+// from cells before they are decoded. It is enabled by default; disable it
+// for columns where the spaces are significant, keeping in mind that
+// numbers, booleans and times then fail to parse if they are padded. The
+// notrim tag option disables trimming for a single field.
+func (r *CSVReader) SetTrimSpace(enabled bool) {
+	r.mu.Lock()
+	r.noTrim = !enabled
+	r.mu.Unlock()
+}
+
+// trim removes surrounding whitespace from value unless trimming is
+// disabled for the reader or the field
+func (r *CSVReader) trim(value string, tag csvTag) string {
+	if r.noTrim || tag.noTrim {
+		return value
+	}
+	return strings.TrimSpace(value)
+}
+
+// SetBufferSize sets the size in bytes of the read buffer. The buffer is
+// allocated when the input is opened, so the new size takes effect from the
+// next Reset; use WithBufferSize to apply it from the start.
+func (r *CSVReader) SetBufferSize(n int) error {
+	if err := validateBufferSize(n); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.opts.bufferSize = n
+	r.mu.Unlock()
+	return nil
+}
+
+// SetVariableColumns allows records to have a different number of fields
+// than the header. Columns missing from a short record leave their fields
+// at the zero value and extra fields are ignored. The trade-off is that
+// truncated or misaligned rows are no longer reported as errors, so only
+// enable it for files that are known to be ragged.
+func (r *CSVReader) SetVariableColumns(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.variable = enabled
+	switch {
+	case enabled:
+		r.reader.FieldsPerRecord = -1
+	case r.noHeader:
+		r.reader.FieldsPerRecord = 0
+	default:
+		r.reader.FieldsPerRecord = len(r.headers)
+	}
+}
+
+// SetNullValues registers tokens such as "NULL", "N/A" or `\N` that mark a
+// missing value. Matching cells are treated exactly like empty cells:
+// pointer fields stay nil, other fields keep their zero value, and defaults
+// and SetEmptyAsError apply. Matching is case-insensitive. Calling it again
+// replaces the previous tokens.
+func (r *CSVReader) SetNullValues(vals ...string) {
+	nullValues := make(map[string]bool, len(vals))
+	for _, v := range vals {
+		nullValues[strings.ToLower(v)] = true
+	}
+
+	r.mu.Lock()
+	r.nullValues = nullValues
+	r.mu.Unlock()
+}
+
+// RegisterConverter registers fn to convert non-empty cells of column
+// instead of the built-in conversion. The returned value must be assignable
+// to the field, or to the element of a pointer field; returning nil leaves
+// the field unchanged. A nil fn removes the converter.
+func (r *CSVReader) RegisterConverter(column string, fn func(string) (interface{}, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.plans = nil
+	if fn == nil {
+		delete(r.converters, column)
+		return
+	}
+	if r.converters == nil {
+		r.converters = make(map[string]func(string) (interface{}, error))
+	}
+	r.converters[column] = fn
+}
+
+// buildHeaderMap maps each header name to its column index. With foldCase
+// the names are trimmed and lowercased, and headers that collide after
+// normalization are reported as an error.
+func buildHeaderMap(headers []string, foldCase bool) (map[string]int, error) {
+	headerMap := make(map[string]int, len(headers))
+	for i, header := range headers {
+		key := header
+		if foldCase {
+			key = normalizeHeader(header)
+			if j, exists := headerMap[key]; exists {
+				return nil, &CSVError{
+					Field:   "headers",
+					Value:   header,
+					Type:    "string",
+					Wrapped: fmt.Errorf("header %q collides with %q", header, headers[j]),
+				}
+			}
+		}
+		headerMap[key] = i
+	}
+	return headerMap, nil
+}
+
+func normalizeHeader(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// SetCaseInsensitiveHeaders makes header matching ignore case and
+// surrounding whitespace in both the file headers and the struct tags.
+// Enabling it fails with a CSVError if two headers differ only by case or
+// whitespace, since they could no longer be told apart.
+func (r *CSVReader) SetCaseInsensitiveHeaders(enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	headerMap, err := buildHeaderMap(r.headers, enabled)
+	if err != nil {
+		return err
+	}
+	r.headerMap = headerMap
+	r.foldCase = enabled
+	r.plans = nil
+	return nil
+}
+
+// SetDelimiter sets the field delimiter used for the remaining records of
+// a reader without a header. When there is a header it has already been
+// read with the old delimiter, so SetDelimiter returns a CSVError wrapping
+// ErrHeaderAlreadyRead; use WithDelimiter instead.
+func (r *CSVReader) SetDelimiter(delim rune) error {
+	if err := validateDelimiter(delim); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkHeaderUnread("delimiter", delim); err != nil {
+		return err
+	}
+	r.reader.Comma = delim
+	r.opts.delimiter = delim
+	return nil
+}
+
+// checkHeaderUnread rejects a change to a setting that affects how the
+// header is parsed once the header has been read
+func (r *CSVReader) checkHeaderUnread(setting string, value rune) error {
+	if r.noHeader {
+		return nil
+	}
+	return &CSVError{
+		Field:   setting,
+		Value:   string(value),
+		Type:    "rune",
+		Wrapped: ErrHeaderAlreadyRead,
+	}
+}
+
+func validateDelimiter(delim rune) error {
+	if delim == '\n' || delim == '\r' || delim == '"' || delim == utf8.RuneError || !utf8.ValidRune(delim) {
+		return &CSVError{
+			Field:   "delimiter",
+			Value:   string(delim),
+			Type:    "rune",
+			Wrapped: fmt.Errorf("invalid delimiter %q", delim),
+		}
+	}
+	return nil
+}
+
+// SetComment skips the remaining lines that start with comment in a
+// reader without a header. A zero rune disables comments. When there is a
+// header it has already been read, so SetComment returns a CSVError
+// wrapping ErrHeaderAlreadyRead; use WithComment instead.
+func (r *CSVReader) SetComment(comment rune) error {
+	if err := validateComment(comment); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkHeaderUnread("comment", comment); err != nil {
+		return err
+	}
+	if comment != 0 && comment == r.reader.Comma {
+		return &CSVError{
+			Field:   "comment",
+			Value:   string(comment),
+			Type:    "rune",
+			Wrapped: fmt.Errorf("comment %q is the same as the delimiter", comment),
+		}
+	}
+	r.reader.Comment = comment
+	r.opts.comment = comment
+	return nil
+}
+
+func validateComment(comment rune) error {
+	if comment == 0 {
+		return nil
+	}
+	if err := validateDelimiter(comment); err != nil {
+		return &CSVError{
+			Field:   "comment",
+			Value:   string(comment),
+			Type:    "rune",
+			Wrapped: fmt.Errorf("invalid comment %q", comment),
+		}
+	}
+	return nil
+}
+
+// SetLazyQuotes tolerates bare quotes in the remaining records. Use
+// WithLazyQuotes when the header itself may contain malformed quoting.
+func (r *CSVReader) SetLazyQuotes(enabled bool) {
+	r.mu.Lock()
+	r.reader.LazyQuotes = enabled
+	r.opts.lazy = enabled
+	r.mu.Unlock()
+}
+
+// ValidateTimeLayout validates the time layout format
+func (r *CSVReader) ValidateTimeLayout(layout string) error {
+	return validateTimeLayout(layout)
+}
+
+func validateTimeLayout(layout string) error {
+	if layout == "" {
+		return fmt.Errorf("time layout cannot be empty")
+	}
+
+	// Verify that layout contains at least year, month, and day components
+	hasYear := strings.Contains(layout, "2006")
+	hasMonth := strings.Contains(layout, "01") || strings.Contains(layout, "Jan")
+	hasDay := strings.Contains(layout, "02")
+
+	if !hasYear || !hasMonth || !hasDay {
+		return fmt.Errorf("invalid time layout: must contain at least year, month, and day components")
+	}
+
+	// Reference time used by Go for time formatting
+	referenceTime := time.Date(2006, time.January, 02, 15, 04, 05, 0, time.UTC)
+	formatted := referenceTime.Format(layout)
+
+	// Try to parse the formatted date using the provided layout
+	parsedTime, err := time.Parse(layout, formatted)
+	if err != nil {
+		return fmt.Errorf("invalid time layout %s: %v", layout, err)
+	}
+
+	// Additional validation: ensure the parsed time matches the reference time
+	// This helps catch cases where the layout might parse successfully but lose information
+	expectedFormatted := parsedTime.Format(layout)
+	if formatted != expectedFormatted {
+		return fmt.Errorf("invalid time layout: inconsistent parsing results")
+	}
+
+	return nil
+}
+
+// readRecord reads the next record and tracks the number of data rows read
+// and the physical line the record started on. Quoted fields may span
+// several lines, so the line is taken from the csv.Reader rather than
+// counted.
+func (r *CSVReader) readRecord() ([]string, error) {
+	if r.limit > 0 && r.rows >= r.limit {
+		return nil, io.EOF
+	}
+
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+
+	r.rows++
+	var parseErr *csv.ParseError
+	if len(record) > 0 {
+		r.line, _ = r.reader.FieldPos(0)
+		r.line += r.lineOffset
+	} else if errors.As(err, &parseErr) {
+		r.line = parseErr.StartLine + r.lineOffset
+	}
+
+	return record, err
+}
+
+// withPosition attaches the current row and line to err, wrapping it in a
+// CSVError if needed. A csv.ParseError is unpacked so its line and column
+// are kept.
+func (r *CSVReader) withPosition(err error) error {
+	if csvErr, ok := err.(*CSVError); ok {
+		csvErr.Row = r.rows
+		csvErr.Line = r.line
+		return csvErr
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &CSVError{
+			Field:   "record",
+			Row:     r.rows,
+			Line:    parseErr.Line + r.lineOffset,
+			Column:  parseErr.Column,
+			Wrapped: parseErr.Err,
+		}
+	}
+	return &CSVError{Field: "record", Row: r.rows, Line: r.line, Wrapped: err}
+}
+
+// ValidateHeaders checks that every field of prototype tagged as required,
+// e.g. csv:"email,,required", has a matching column in the header. The
+// returned CSVError lists all missing columns.
+func (r *CSVReader) ValidateHeaders(prototype interface{}) error {
+	protoValue, err := structValue(prototype)
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	missing := r.missingColumns(protoValue.Type(), "", nil, false, nil)
+	if len(missing) > 0 {
+		return &CSVError{
+			Field:   "headers",
+			Value:   strings.Join(missing, ","),
+			Type:    "required",
+			Wrapped: fmt.Errorf("%w: %s", ErrColumnMissing, strings.Join(missing, ", ")),
+		}
+	}
+	return nil
+}
+
+// ExpectHeaders checks that the header matches expected exactly, in the
+// same order, so schema drift is caught before any rows are read. The
+// returned CSVError describes the first position that differs. Case and
+// surrounding whitespace are ignored when SetCaseInsensitiveHeaders is on.
+func (r *CSVReader) ExpectHeaders(expected []string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := 0; i < max(len(expected), len(r.headers)); i++ {
+		var want, got string
+		if i < len(expected) {
+			want = expected[i]
+		}
+		if i < len(r.headers) {
+			got = r.headers[i]
+		}
+
+		switch {
+		case i >= len(r.headers):
+			return &CSVError{
+				Field:   "headers",
+				Type:    "string",
+				Wrapped: fmt.Errorf("%w: column %d: expected %q, header ends after %d columns", ErrColumnMissing, i+1, want, len(r.headers)),
+			}
+		case i >= len(expected):
+			return &CSVError{
+				Field:   "headers",
+				Value:   got,
+				Type:    "string",
+				Wrapped: fmt.Errorf("column %d: unexpected extra column %q", i+1, got),
+			}
+		case r.foldCase && normalizeHeader(got) == normalizeHeader(want), got == want:
+			continue
+		default:
+			return &CSVError{
+				Field:   "headers",
+				Value:   got,
+				Type:    "string",
+				Wrapped: fmt.Errorf("column %d: expected %q, got %q", i+1, want, got),
+			}
+		}
+	}
+	return nil
+}
+
+// missingColumns returns the columns of structType that are absent from the
+// header, limited to required fields unless all is set. path holds the
+// struct types enclosing structType.
+func (r *CSVReader) missingColumns(structType reflect.Type, prefix string, path []reflect.Type, all bool, missing []string) []string {
+	path = append(path, structType)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		embedded := isEmbeddedStruct(field)
+		if !embedded && !field.IsExported() && !hasSetter(field) {
+			continue
+		}
+		if isRecursive(field, path) {
+			continue
+		}
+
+		tag := r.fieldTag(field, prefix)
+		if !embedded && tag.name == "-" {
+			continue
+		}
+
+		if embedded {
+			missing = r.missingColumns(indirectType(field.Type), prefix, path, all, missing)
+			continue
+		}
+		if isNestedStruct(field) {
+			missing = r.missingColumns(indirectType(field.Type), prefix+tag.name+".", path, all, missing)
+			continue
+		}
+
+		if !all && !tag.required {
+			continue
+		}
+		if isWildcard(prefix+tag.name) && isWildcardMap(field.Type) {
+			// A wildcard matching no header leaves the map empty
+			continue
+		}
+		if _, ok := r.columnIndex(prefix + tag.name); !ok {
+			missing = append(missing, prefix+tag.name)
+		}
+	}
+	return missing
+}
+
+// ReadNext reads the next record and populates the provided struct.
+// At the end of the input it returns io.EOF unwrapped; malformed records
+// are reported as a CSVError carrying the line number. It is safe to call
+// from multiple goroutines; calls are serialized and each record is
+// delivered to exactly one caller.
+//
+// Fields whose cell is empty are reset to their zero value, and non-nil
+// pointer fields are reused rather than reallocated, so dest can be read
+// into repeatedly without allocating. Copy the values a pointer field
+// points to if they must outlive the next call.
+//
+// dest may also be a pointer to a struct pointer; a nil struct pointer is
+// allocated before it is populated.
+func (r *CSVReader) ReadNext(dest interface{}) error {
+	return r.ReadNextCtx(context.Background(), dest)
+}
+
+// ReadNextCtx is like ReadNext but returns ctx.Err() without reading if
+// ctx is canceled or its deadline has passed
+func (r *CSVReader) ReadNextCtx(ctx context.Context, dest interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check the destination before reading so a bad one costs no record
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
+		return &CSVError{Field: "destination", Type: "pointer",
+			Value: fmt.Sprintf("%T", dest), Wrapped: ErrNotPointer}
+	}
+	destValue = destValue.Elem()
+	structType := indirectType(destValue.Type())
+	if structType.Kind() != reflect.Struct {
+		return &CSVError{Field: "destination", Type: "struct",
+			Value: fmt.Sprintf("%T", dest), Wrapped: ErrNotStruct}
+	}
+	p := r.plan(structType)
+	if err := checkFields(structType, p); err != nil {
+		return err
+	}
+	if err := r.checkStrict(structType, p); err != nil {
+		return err
+	}
+
+	record, err := r.readRecord()
+	if err == io.EOF {
+		return err
+	}
+	if err != nil {
+		return r.withPosition(err)
+	}
+
+	if destValue.Kind() == reflect.Ptr {
+		if destValue.IsNil() {
+			destValue.Set(reflect.New(structType))
+		}
+		destValue = destValue.Elem()
+	}
+	if err := r.populateFields(destValue, record, p.fields); err != nil {
+		return r.withPosition(err)
+	}
+	return nil
+}
+
+// ReadAll reads all remaining records into dest, which must be a pointer
+// to a slice of structs or struct pointers. Each pointer element is newly
+// allocated.
+func (r *CSVReader) ReadAll(dest interface{}) error {
+	return r.ReadAllCtx(context.Background(), dest)
+}
+
+// ReadAllCtx is like ReadAll but checks ctx before each record and stops
+// with ctx.Err() once it is canceled. Records decoded so far are kept in
+// dest.
+func (r *CSVReader) ReadAllCtx(ctx context.Context, dest interface{}) error {
+	_, err := r.readAll(ctx, dest, false, "")
+	return err
+}
+
+// ReadAllLenient reads all remaining records into dest like ReadAll, but
+// keeps going when a row is malformed. Rows that fail are skipped and
+// reported in the returned slice; rows with more or fewer fields than the
+// header are among them, with errors matching csv.ErrFieldCount. The error
+// is only non-nil for problems that stop reading altogether, such as an
+// invalid destination or an I/O failure.
+func (r *CSVReader) ReadAllLenient(dest interface{}) ([]RowError, error) {
+	return r.readAll(context.Background(), dest, true, "")
+}
+
+// ReadAllWithLayout reads all remaining records into dest like ReadAll,
+// using layout instead of the reader's time layout for time fields without
+// a format of their own. The reader's layout is untouched, so consumers
+// sharing a reader do not have to coordinate calls to SetTimeLayout.
+func (r *CSVReader) ReadAllWithLayout(dest interface{}, layout string) error {
+	if err := r.ValidateTimeLayout(layout); err != nil {
+		return &CSVError{
+			Field:   "timeLayout",
+			Value:   layout,
+			Type:    "string",
+			Wrapped: err,
+		}
+	}
+	_, err := r.readAll(context.Background(), dest, false, layout)
+	return err
+}
+
+// readAll reads the remaining records into dest. A non-empty layout
+// replaces the reader's time layout for the duration of the call.
+func (r *CSVReader) readAll(ctx context.Context, dest interface{}, lenient bool, layout string) ([]RowError, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if layout != "" {
+		defer r.swapTimeLayout(layout)()
+	}
+
+	sliceValue := reflect.ValueOf(dest)
+	if sliceValue.Kind() != reflect.Ptr || sliceValue.IsNil() {
+		return nil, &CSVError{Field: "destination", Type: "pointer",
+			Value: fmt.Sprintf("%T", dest), Wrapped: ErrNotPointer}
+	}
+
+	sliceValue = sliceValue.Elem()
+	if sliceValue.Kind() != reflect.Slice || indirectType(sliceValue.Type().Elem()).Kind() != reflect.Struct {
+		return nil, &CSVError{Field: "destination", Type: "slice of structs",
+			Value: fmt.Sprintf("%T", dest), Wrapped: ErrNotStruct}
+	}
+
+	// Lenient reads check the field count themselves, so a short or long
+	// row is recorded like any other bad row rather than left to the csv
+	// package. Zero means the first record sets the count, as it does there.
+	expected := r.reader.FieldsPerRecord
+	if lenient && expected >= 0 {
+		r.reader.FieldsPerRecord = -1
+		defer func() { r.reader.FieldsPerRecord = expected }()
+	}
+
+	var rowErrors []RowError
+	elemPtr := sliceValue.Type().Elem().Kind() == reflect.Ptr
+	elemType := indirectType(sliceValue.Type().Elem())
+	// Checked up front so a lenient read does not report it for every row
+	if err := checkFields(elemType, r.plan(elemType)); err != nil {
+		return nil, err
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return rowErrors, err
+		}
+
+		record, err := r.readRecord()
+		if err == io.EOF {
+			return rowErrors, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !lenient || !errors.As(err, &parseErr) {
+				return rowErrors, r.withPosition(err)
+			}
+			rowErrors = append(rowErrors, RowError{Line: r.line, Err: r.withPosition(err)})
+			continue
+		}
+		if lenient && expected == 0 {
+			expected = len(record)
+		}
+		if lenient && expected > 0 && len(record) != expected {
+			err := &CSVError{
+				Field:   "record",
+				Wrapped: fmt.Errorf("%w: got %d fields, want %d", csv.ErrFieldCount, len(record), expected),
+			}
+			rowErrors = append(rowErrors, RowError{Line: r.line, Err: r.withPosition(err)})
+			continue
+		}
+
+		elem := reflect.New(elemType)
+		if err := r.populateStruct(elem.Elem(), record); err != nil {
+			if !lenient {
+				return rowErrors, r.withPosition(err)
+			}
+			rowErrors = append(rowErrors, RowError{Line: r.line, Err: r.withPosition(err)})
+			continue
+		}
+		if !elemPtr {
+			elem = elem.Elem()
+		}
+		sliceValue.Set(reflect.Append(sliceValue, elem))
+	}
+}
+
+// fieldPlan is the precomputed mapping of one struct field to its column,
+// so the per-row loop does not parse tags or look up headers
+type fieldPlan struct {
+	index      int
+	name       string
+	fieldName  string
+	column     int // -1 when the column is absent
+	tag        csvTag
+	nonEmpty   bool
+	convert    func(string) (interface{}, error)
+	nested     []fieldPlan // fields of an embedded or nested struct
+	isNested   bool
+	hasColumns bool             // whether a nested struct has any column to read
+	wildcard   []wildcardColumn // columns gathered into a map field
+	isMap      bool
+	setter     *reflect.Method // method named by the setter tag option
+	err        error           // why the field cannot be read
+}
+
+// structPlan is the cached plan for one destination struct type
+type structPlan struct {
+	fields   []fieldPlan
+	missing  []string // columns absent from the header, for StrictColumns
+	mappable bool     // whether any field can be read into at all
+	invalid  error    // the first field that cannot be read, if any
+}
+
+// Prepare analyzes the struct type of prototype ahead of the first read.
+// Reads analyze each destination type once and reuse the result, so
+// calling Prepare is optional; it moves that work out of the first read
+// and, before any row is consumed, reports StrictColumns violations and
+// structs without a single mappable field (ErrNoMappableFields).
+func (r *CSVReader) Prepare(prototype interface{}) error {
+	protoValue, err := structValue(prototype)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := r.plan(protoValue.Type())
+	if err := checkFields(protoValue.Type(), p); err != nil {
+		return err
+	}
+	return r.checkStrict(protoValue.Type(), p)
+}
+
+// plan returns the cached plan for structType, building it on first use.
+// Setters that change how fields map to columns clear the cache.
+func (r *CSVReader) plan(structType reflect.Type) *structPlan {
+	if p, ok := r.plans[structType]; ok {
+		return p
+	}
+
+	p := &structPlan{
+		fields:   r.planFields(structType, "", nil),
+		mappable: r.hasMappableField(structType, nil),
+	}
+	p.invalid = firstFieldError(p.fields)
+	if r.strict {
+		p.missing = r.missingColumns(structType, "", nil, true, nil)
+	}
+	if r.warn != nil && !r.warned[structType] {
+		// Plans are rebuilt whenever a setter clears them, but the
+		// headers only need reporting once
+		r.warnUnmapped(p.fields)
+		if r.warned == nil {
+			r.warned = make(map[reflect.Type]bool)
+		}
+		r.warned[structType] = true
+	}
+	if r.plans == nil {
+		r.plans = make(map[reflect.Type]*structPlan)
+	}
+	r.plans[structType] = p
+	return p
+}
+
+func (r *CSVReader) planFields(structType reflect.Type, prefix string, path []reflect.Type) []fieldPlan {
+	path = append(path, structType)
+	var fields []fieldPlan
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		embedded := isEmbeddedStruct(field)
+		if !embedded && !field.IsExported() && !hasSetter(field) {
+			continue
+		}
+		if isRecursive(field, path) {
+			continue
+		}
+
+		tag := r.fieldTag(field, prefix)
+		if !embedded && tag.name == "-" {
+			continue
+		}
+
+		if embedded || isNestedStruct(field) {
+			nestedPrefix := prefix
+			if !embedded {
+				nestedPrefix = prefix + tag.name + "."
+			}
+			nested := r.planFields(indirectType(field.Type), nestedPrefix, path)
+			fields = append(fields, fieldPlan{
+				index:      i,
+				nested:     nested,
+				isNested:   true,
+				hasColumns: hasColumns(nested),
+			})
+			continue
+		}
+
+		name := prefix + tag.name
+		if isWildcard(name) && isWildcardMap(field.Type) {
+			fields = append(fields, fieldPlan{
+				index:     i,
+				name:      name,
+				fieldName: strings.ToLower(field.Name),
+				tag:       tag,
+				wildcard:  r.wildcardColumns(name),
+				isMap:     true,
+			})
+			continue
+		}
+
+		column, ok := r.columnIndex(name)
+		if !ok {
+			column = -1
+		}
+		if column >= 0 && r.selected != nil && !r.selected[column] {
+			// Leave fields of unselected columns out of the plan entirely
+			continue
+		}
+		fp := fieldPlan{
+			index:     i,
+			name:      name,
+			fieldName: strings.ToLower(field.Name),
+			column:    column,
+			tag:       tag,
+			nonEmpty:  r.nonEmpty[name] || tag.required,
+			convert:   r.converters[name],
+		}
+		if tag.setter != "" {
+			fp.setter, fp.err = setterMethod(structType, name, tag.setter)
+		}
+		if index, ok := parseAbsolutePosition(name); ok && !r.noHeader && index >= len(r.headers) {
+			fp.err = &CSVError{
+				Field:   name,
+				Wrapped: fmt.Errorf("%w: position %d is beyond the %d header columns", ErrColumnMissing, index, len(r.headers)),
+			}
+		}
+		fields = append(fields, fp)
+	}
+	return fields
+}
+
+// hasMappableField reports whether structType, or a struct nested in it,
+// has an exported field, or one with a setter, that is not tagged csv:"-"
+func (r *CSVReader) hasMappableField(structType reflect.Type, path []reflect.Type) bool {
+	path = append(path, structType)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		embedded := isEmbeddedStruct(field)
+		if !embedded && !field.IsExported() && !hasSetter(field) {
+			continue
+		}
+		if isRecursive(field, path) {
+			continue
+		}
+		if !embedded && r.fieldTag(field, "").name == "-" {
+			continue
+		}
+		if !embedded && !isNestedStruct(field) {
+			return true
+		}
+		if r.hasMappableField(indirectType(field.Type), path) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstFieldError returns the first field among fields and the fields of
+// their nested structs that cannot be read, such as one with an unusable
+// setter
+func firstFieldError(fields []fieldPlan) error {
+	for _, fp := range fields {
+		if fp.err != nil {
+			return fp.err
+		}
+		if err := firstFieldError(fp.nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFields reports a struct type that no column can be read into,
+// which is almost always a tagging mistake, and fields whose setter
+// method cannot be called
+func checkFields(structType reflect.Type, p *structPlan) error {
+	if p.invalid != nil {
+		return p.invalid
+	}
+	if p.mappable {
+		return nil
+	}
+	return &CSVError{
+		Field: "destination",
+		Wrapped: fmt.Errorf("%w: every field of %s is unexported or tagged csv:\"-\"",
+			ErrNoMappableFields, structType),
+	}
+}
+
+// checkStrict reports the fields of p without a column when StrictColumns
+// is enabled
+func (r *CSVReader) checkStrict(structType reflect.Type, p *structPlan) error {
+	if !r.strict || len(p.missing) == 0 {
+		return nil
+	}
+	return &CSVError{
+		Field:   "headers",
+		Value:   strings.Join(p.missing, ","),
+		Type:    structType.String(),
+		Wrapped: fmt.Errorf("%w for fields: %s", ErrColumnMissing, strings.Join(p.missing, ", ")),
+	}
+}
+
+func (r *CSVReader) populateStruct(destValue reflect.Value, record []string) error {
+	p := r.plan(destValue.Type())
+	if err := checkFields(destValue.Type(), p); err != nil {
+		return err
+	}
+	if err := r.checkStrict(destValue.Type(), p); err != nil {
+		return err
+	}
+	return r.populateFields(destValue, record, p.fields)
+}
+
+// populateFields populates the fields of destValue following fields
+func (r *CSVReader) populateFields(destValue reflect.Value, record []string, fields []fieldPlan) error {
+	for _, fp := range fields {
+		fieldValue := destValue.Field(fp.index)
+
+		if fp.isNested {
+			if !fp.hasColumns {
+				// Leave optional nested pointers nil when none of
+				// their columns are present
+				continue
+			}
+			nested, ok := structTarget(fieldValue)
+			if !ok {
+				continue
+			}
+			if err := r.populateFields(nested, record, fp.nested); err != nil {
+				return err
+			}
+			continue
+		}
+		if fp.isMap {
+			if err := r.populateMap(fieldValue, record, fp); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if fp.column < 0 {
+			continue
+		}
+
+		if fp.column >= len(record) {
+			if r.variable {
+				continue
+			}
+			return &CSVError{Field: fp.name, Value: "index out of range"}
+		}
+
+		// A non-empty cell always wins; the default only replaces empty cells
+		value := r.trim(record[fp.column], fp.tag)
+		if len(fp.tag.transforms) > 0 {
+			var err error
+			if value, err = applyTransforms(fp.name, value, fp.tag.transforms); err != nil {
+				return err
+			}
+		}
+		if len(r.nullValues) > 0 && r.nullValues[strings.ToLower(value)] {
+			value = ""
+		}
+		if value == "" && fp.tag.hasDefault {
+			value = fp.tag.defaultValue
+		}
+		if value == "" && fp.nonEmpty {
+			// No Value or Type, so the message reads on its own
+			return &CSVError{Field: fp.name, Wrapped: emptyValueError(fp.tag)}
+		}
+		if value == "" {
+			r.emit(Warning{Kind: WarningEmptyCell, Column: fp.name})
+
+			// Reset the field in case dest is reused across rows. Empty
+			// cells leave slices empty rather than nil.
+			if fp.setter != nil {
+				if _, err := r.callSetter(destValue, fp, ""); err != nil {
+					return err
+				}
+			} else if fieldValue.Kind() == reflect.Slice && isSplitSlice(fieldValue) {
+				fieldValue.Set(reflect.MakeSlice(fieldValue.Type(), 0, 0))
+			} else {
+				fieldValue.SetZero()
+			}
+			continue
+		}
+
+		var err error
+		switch {
+		case fp.setter != nil:
+			// Range checks apply to the value handed to the setter
+			fieldValue, err = r.callSetter(destValue, fp, value)
+		case fp.convert != nil:
+			err = assignConverted(fieldValue, fp.name, value, fp.convert)
+		default:
+			err = r.setFieldValue(fieldValue, value, fp.tag, fp.fieldName)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := checkOneOf(fp.name, value, fp.tag); err != nil {
+			return err
+		}
+		if err := checkRange(fp.name, value, fieldValue, fp.tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// emptyValueError describes a required or non-empty cell that was empty,
+// using the msg option of the tag when there is one
+func emptyValueError(tag csvTag) error {
+	if tag.message != "" {
+		return errors.New(tag.message)
+	}
+	return fmt.Errorf("empty value not allowed")
+}
+
+// assignConverted runs a registered converter and assigns its result
+func assignConverted(fieldValue reflect.Value, name, value string, convert func(string) (interface{}, error)) error {
+	converted, err := convert(value)
+	if err != nil {
+		return &CSVError{
+			Field:   name,
+			Value:   value,
+			Type:    fieldValue.Type().String(),
+			Wrapped: err,
+		}
+	}
+	if converted == nil {
+		return nil
+	}
+
+	convertedValue := reflect.ValueOf(converted)
+	switch {
+	case convertedValue.Type().AssignableTo(fieldValue.Type()):
+		fieldValue.Set(convertedValue)
+	case fieldValue.Kind() == reflect.Ptr && convertedValue.Type().AssignableTo(fieldValue.Type().Elem()):
+		ptr := reflect.New(fieldValue.Type().Elem())
+		ptr.Elem().Set(convertedValue)
+		fieldValue.Set(ptr)
+	default:
+		return &CSVError{
+			Field: name,
+			Value: value,
+			Type:  fieldValue.Type().String(),
+			Wrapped: fmt.Errorf("converter returned %s, not assignable to %s",
+				convertedValue.Type(), fieldValue.Type()),
+		}
 	}
-
 	return nil
 }
 
-// ReadNext reads the next record and populates the provided struct
-func (r *CSVReader) ReadNext(dest interface{}) error {
-	record, err := r.reader.Read()
-	if err != nil {
-		return err
+// structTarget returns the struct held by fieldValue, allocating it when
+// fieldValue is a nil pointer
+func structTarget(fieldValue reflect.Value) (reflect.Value, bool) {
+	if fieldValue.Kind() != reflect.Ptr {
+		return fieldValue, true
+	}
+	if fieldValue.IsNil() {
+		if !fieldValue.CanSet() {
+			return reflect.Value{}, false
+		}
+		fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
 	}
+	return fieldValue.Elem(), true
+}
 
-	destValue := reflect.ValueOf(dest)
-	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
-		return &CSVError{Field: "destination", Type: "pointer",
-			Value: fmt.Sprintf("%T", dest)}
+// isEmbeddedStruct reports whether field is an untagged embedded struct,
+// or pointer to struct, whose fields are treated as if they were declared
+// on the parent
+func isEmbeddedStruct(field reflect.StructField) bool {
+	return field.Anonymous && field.Tag.Get("csv") == "" && isNestedStruct(field)
+}
+
+// isNestedStruct reports whether field holds a struct, or pointer to
+// struct, whose fields map to their own columns. Named fields use their tag
+// as a column prefix, e.g. csv:"address" maps to "address.city". Types that
+// are encoded as a single cell, such as time.Time or types implementing
+// CSVUnmarshaler or the encoding.Text interfaces, are not nested.
+func isNestedStruct(field reflect.StructField) bool {
+	fieldType := indirectType(field.Type)
+	if fieldType.Kind() != reflect.Struct || fieldType == reflect.TypeOf(time.Time{}) {
+		return false
+	}
+	// A struct held as JSON in a single cell is not flattened
+	if parseCSVTag(field, "").json {
+		return false
 	}
 
-	destValue = destValue.Elem()
-	if destValue.Kind() != reflect.Struct {
-		return &CSVError{Field: "destination", Type: "struct",
-			Value: fmt.Sprintf("%T", dest)}
+	ptrType := reflect.PointerTo(fieldType)
+	for _, iface := range []reflect.Type{csvUnmarshalerType, textUnmarshalerType, textMarshalerType} {
+		if ptrType.Implements(iface) {
+			return false
+		}
 	}
+	return true
+}
 
-	return r.populateStruct(destValue, record)
+// isRecursive reports whether field holds a struct type already on path,
+// the struct types being walked. Such a field, like Next *Node on Node,
+// would expand forever and is skipped.
+func isRecursive(field reflect.StructField, path []reflect.Type) bool {
+	if !isNestedStruct(field) {
+		return false
+	}
+	fieldType := indirectType(field.Type)
+	for _, t := range path {
+		if t == fieldType {
+			return true
+		}
+	}
+	return false
 }
 
-func (r *CSVReader) populateStruct(destValue reflect.Value, record []string) error {
-	destType := destValue.Type()
+// hasColumns reports whether any field of a plan, or of the structs
+// nested in it, is backed by a column
+func hasColumns(fields []fieldPlan) bool {
+	for _, fp := range fields {
+		if fp.column >= 0 && !fp.isNested && !fp.isMap || len(fp.wildcard) > 0 || fp.hasColumns {
+			return true
+		}
+	}
+	return false
+}
 
-	for i := 0; i < destType.NumField(); i++ {
-		field := destType.Field(i)
-		fieldValue := destValue.Field(i)
+// indirectType returns the element type of pointer types
+func indirectType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
 
-		if !fieldValue.CanSet() {
-			continue
+// columnIndex returns the record index for a tag name, falling back to
+// its header aliases. Readers without a header map positional names such
+// as "[2]" or "2" instead. An absolute position such as "@2" bypasses the
+// header in either case.
+func (r *CSVReader) columnIndex(name string) (int, bool) {
+	if index, ok := parseAbsolutePosition(name); ok {
+		if !r.noHeader && index >= len(r.headers) {
+			return 0, false
 		}
-
-		tag := r.parseCSVTag(field)
-		if tag.name == "-" {
-			continue
+		return index, true
+	}
+	if r.noHeader {
+		return parsePosition(name)
+	}
+	if index, ok := r.headerIndex(name); ok {
+		return index, true
+	}
+	for _, alias := range r.aliases[name] {
+		if index, ok := r.headerIndex(alias); ok {
+			return index, true
 		}
+	}
+	return 0, false
+}
 
-		columnIndex, ok := r.headerMap[tag.name]
-		if !ok {
-			continue
-		}
+// headerIndex returns the record index of the header name
+func (r *CSVReader) headerIndex(name string) (int, bool) {
+	if r.foldCase {
+		name = normalizeHeader(name)
+	}
+	index, ok := r.headerMap[name]
+	return index, ok
+}
 
-		if columnIndex >= len(record) {
-			return &CSVError{Field: tag.name, Value: "index out of range"}
-		}
+// maxExactFloatInt is the largest magnitude below which a float64 holds
+// every integer exactly
+const maxExactFloatInt = 1 << 53
 
-		value := strings.TrimSpace(record[columnIndex])
-		if value == "" {
-			continue
-		}
+// parseScientificInt parses an integer written in scientific notation,
+// such as 1.23E+08, for SetAcceptScientificInts. Values with a fractional
+// part or too large to be exact in a float64 are rejected; text that is
+// not a float at all keeps intErr, the error of the integer parse.
+func parseScientificInt(number string, intErr error) (float64, error) {
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, intErr
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("%s is not a whole number", number)
+	}
+	if math.Abs(f) > maxExactFloatInt {
+		return 0, fmt.Errorf("%s is too large to convert exactly", number)
+	}
+	return f, nil
+}
 
-		if err := r.setFieldValue(fieldValue, value, tag.timeFormat, field.Name); err != nil {
-			return err
-		}
+// parseAbsolutePosition parses a zero-based column position written as
+// "@N". Below a nested struct prefix it is the last segment, as in
+// "address.@3".
+func parseAbsolutePosition(name string) (int, bool) {
+	i := strings.LastIndex(name, "@")
+	if i < 0 || (i > 0 && name[i-1] != '.') {
+		return 0, false
+	}
+	index, err := strconv.Atoi(name[i+1:])
+	if err != nil || index < 0 || strings.HasPrefix(name[i+1:], "+") {
+		return 0, false
+	}
+	return index, true
+}
+
+// parsePosition parses a column position written as "[N]" or "N"
+func parsePosition(name string) (int, bool) {
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		name = name[1 : len(name)-1]
 	}
+	index, err := strconv.Atoi(name)
+	if err != nil || index < 0 {
+		return 0, false
+	}
+	return index, true
+}
 
-	return nil
+// fieldTag parses the csv tag of field, applying any column mapping. A
+// field without a column name in its tag is matched against the header,
+// below prefix, with the name matcher.
+func (r *CSVReader) fieldTag(field reflect.StructField, prefix string) csvTag {
+	tag := parseCSVTag(field, r.timeLayout)
+	if column, ok := r.mapping[field.Name]; ok {
+		tag.name = column
+		return tag
+	}
+	if name, _, _ := strings.Cut(field.Tag.Get("csv"), ","); name == "" && r.nameMatcher != nil {
+		tag.name = r.matchHeader(field.Name, prefix)
+	}
+	return tag
+}
+
+// matchHeader returns the header below prefix that the name matcher pairs
+// with fieldName. An exact match wins; otherwise the first matching header
+// is used, and fieldName itself if there is none.
+func (r *CSVReader) matchHeader(fieldName, prefix string) string {
+	if r.noHeader {
+		return fieldName
+	}
+	if _, ok := r.columnIndex(prefix + fieldName); ok {
+		return fieldName
+	}
+	for _, header := range r.headers {
+		rest, ok := strings.CutPrefix(header, prefix)
+		if ok && r.nameMatcher(fieldName, rest) {
+			return rest
+		}
+	}
+	return fieldName
 }
 
+// csvTag holds the parsed csv struct tag of a field
 type csvTag struct {
-	name       string
-	timeFormat string
+	name         string
+	timeFormat   string
+	defaultValue string
+	hasDefault   bool
+	required     bool
+	omitEmpty    bool
+	encoding     string
+	base         int
+	noTrim       bool
+	json         bool
+	oneOf        []string
+	transforms   []string
+	min, max     float64
+	hasMin       bool
+	hasMax       bool
+	percent      bool
+	currency     string
+	accounting   bool
+	message      string
+	setter       string
 }
 
-func (r *CSVReader) parseCSVTag(field reflect.StructField) csvTag {
-	tag := field.Tag.Get("csv")
-	if tag == "" {
-		return csvTag{name: field.Name, timeFormat: r.timeLayout}
+// parseCSVTag parses the csv struct tag of a field. It is shared by the
+// reader and the writer so both sides agree on column names and formats.
+//
+// The tag is a column name followed by comma-separated options, either
+// flags such as "required", "omitempty", "notrim", "json", "percent",
+// "currency" and "accounting" or key=value pairs such as
+// "format=2006-01-02", "default=0", "encoding=base64", "base=16",
+// "oneof=new paid", "min=0", "max=120", "transform=trim upper",
+// "currency=€" and "setter=SetAmount". A "msg=" option, which must come
+// last as it runs to the end of the tag, replaces the error message of a
+// required field left empty. For backward compatibility a bare second
+// part that is not an option, as in csv:"date,02/01/2006", is the time
+// format. An empty name or format falls back to the field name and
+// defaultLayout. The separate default struct tag is still honored, but a
+// default option in the csv tag takes precedence.
+func parseCSVTag(field reflect.StructField, defaultLayout string) csvTag {
+	tag := csvTag{name: field.Name, timeFormat: defaultLayout, base: 10}
+	tag.defaultValue, tag.hasDefault = field.Tag.Lookup("default")
+
+	value := field.Tag.Get("csv")
+	if value == "" {
+		return tag
 	}
 
-	parts := strings.Split(tag, ",")
-	if len(parts) == 1 {
-		return csvTag{name: parts[0], timeFormat: r.timeLayout}
+	parts := strings.Split(value, ",")
+	if parts[0] != "" {
+		tag.name = parts[0]
+	}
+	for i, part := range parts[1:] {
+		key, optValue, hasValue := strings.Cut(part, "=")
+		if hasValue && key == "msg" {
+			// The message runs to the end of the tag, so it may hold commas
+			tag.message = strings.Join(append([]string{optValue}, parts[i+2:]...), ",")
+			break
+		}
+		switch {
+		case part == "":
+		case hasValue && key == "format":
+			if optValue != "" {
+				tag.timeFormat = optValue
+			}
+		case hasValue && key == "default":
+			tag.defaultValue, tag.hasDefault = optValue, true
+		case hasValue && key == "encoding":
+			tag.encoding = optValue
+		case hasValue && key == "oneof":
+			tag.oneOf = strings.Fields(optValue)
+		case hasValue && key == "setter":
+			tag.setter = optValue
+		case hasValue && key == "transform":
+			tag.transforms = strings.Fields(optValue)
+		case hasValue && (key == "min" || key == "max"):
+			// An invalid bound is reported by checkRange when a cell is read
+			bound, err := strconv.ParseFloat(optValue, 64)
+			if err != nil {
+				bound = math.NaN()
+			}
+			if key == "min" {
+				tag.min, tag.hasMin = bound, true
+			} else {
+				tag.max, tag.hasMax = bound, true
+			}
+		case hasValue && key == "base":
+			// An invalid base is reported by strconv when a cell is parsed
+			base, err := strconv.Atoi(optValue)
+			if err != nil {
+				base = -1
+			}
+			tag.base = base
+		case part == "required":
+			tag.required = true
+		case part == "omitempty":
+			tag.omitEmpty = true
+		case part == "notrim":
+			tag.noTrim = true
+		case part == "json":
+			tag.json = true
+		case part == "percent":
+			tag.percent = true
+		case part == "accounting":
+			tag.accounting = true
+		case part == "currency":
+			tag.currency = defaultCurrencySymbol
+		case hasValue && key == "currency":
+			tag.currency = optValue
+		case i == 0 && !hasValue:
+			tag.timeFormat = part
+		}
 	}
 
-	return csvTag{name: parts[0], timeFormat: parts[1]}
+	return tag
 }
 
-func (r *CSVReader) setFieldValue(fieldValue reflect.Value, value string, timeFormat, fieldName string) error {
+func (r *CSVReader) setFieldValue(fieldValue reflect.Value, value string, tag csvTag, fieldName string) error {
 	fieldNameLower := strings.ToLower(fieldName)
 
-	// Handle pointer types
+	// Handle cells holding JSON, which may decode into any type
+	if tag.json {
+		if err := json.Unmarshal([]byte(value), fieldValue.Addr().Interface()); err != nil {
+			return &CSVError{
+				Field:   fieldNameLower,
+				Value:   value,
+				Type:    "json",
+				Wrapped: err,
+			}
+		}
+		return nil
+	}
+
+	// Handle pointer types, reusing the target of a non-nil pointer so a
+	// destination read into repeatedly does not allocate on every row
 	if fieldValue.Kind() == reflect.Ptr {
 		if fieldValue.IsNil() {
 			fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
 		}
-		return r.setFieldValue(fieldValue.Elem(), value, timeFormat, fieldName)
+		return r.setFieldValue(fieldValue.Elem(), value, tag, fieldName)
+	}
+
+	// Handle types that decode themselves
+	if fieldValue.CanAddr() && fieldValue.Addr().Type().Implements(csvUnmarshalerType) {
+		if err := fieldValue.Addr().Interface().(CSVUnmarshaler).UnmarshalCSV(value); err != nil {
+			return &CSVError{
+				Field:   fieldNameLower,
+				Value:   value,
+				Type:    fieldValue.Type().String(),
+				Wrapped: err,
+			}
+		}
+		return nil
 	}
 
 	// Handle time.Time
 	if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
-		return r.setTimeValue(fieldValue, value, timeFormat, fieldNameLower)
+		return r.setTimeValue(fieldValue, value, tag.timeFormat, fieldNameLower)
+	}
+
+	// Handle time.Duration, which would otherwise be parsed as an int64
+	if fieldValue.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return &CSVError{
+				Field:   fieldNameLower,
+				Value:   value,
+				Type:    "time.Duration",
+				Wrapped: err,
+			}
+		}
+		fieldValue.SetInt(int64(d))
+		return nil
+	}
+
+	// Handle arbitrary-precision numbers, honoring the configured separators
+	// that their UnmarshalText methods would not know about
+	switch fieldValue.Type() {
+	case reflect.TypeOf(big.Int{}):
+		if _, ok := fieldValue.Addr().Interface().(*big.Int).SetString(r.stripGrouping(value), 10); !ok {
+			return &CSVError{
+				Field:   fieldNameLower,
+				Value:   value,
+				Type:    "*big.Int",
+				Wrapped: fmt.Errorf("invalid integer"),
+			}
+		}
+		return nil
+
+	case reflect.TypeOf(big.Float{}):
+		if _, ok := fieldValue.Addr().Interface().(*big.Float).SetString(r.normalizeFloat(value)); !ok {
+			return &CSVError{
+				Field:   fieldNameLower,
+				Value:   value,
+				Type:    "*big.Float",
+				Wrapped: fmt.Errorf("invalid floating-point number"),
+			}
+		}
+		return nil
+	}
+
+	// Handle types implementing encoding.TextUnmarshaler, such as net.IP.
+	// time.Time is excluded above so the configured layouts still apply.
+	if fieldValue.CanAddr() && fieldValue.Addr().Type().Implements(textUnmarshalerType) {
+		if err := fieldValue.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return &CSVError{
+				Field:   fieldNameLower,
+				Value:   value,
+				Type:    fieldValue.Type().String(),
+				Wrapped: err,
+			}
+		}
+		return nil
 	}
 
 	// Handle basic types
@@ -201,7 +2261,17 @@ func (r *CSVReader) setFieldValue(fieldValue reflect.Value, value string, timeFo
 		return nil
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		intVal, err := strconv.ParseInt(value, 10, 64)
+		number := trimBasePrefix(r.stripGrouping(r.unformatNumber(value, tag)), tag.base)
+		intVal, err := strconv.ParseInt(number, tag.base, 64)
+		if err != nil && r.sciInts {
+			if f, sciErr := parseScientificInt(number, err); sciErr != nil {
+				err = sciErr
+			} else if fieldValue.OverflowInt(int64(f)) {
+				err = fmt.Errorf("%s is out of range", number)
+			} else {
+				intVal, err = int64(f), nil
+			}
+		}
 		if err != nil {
 			return &CSVError{
 				Field:   fieldNameLower,
@@ -213,8 +2283,40 @@ func (r *CSVReader) setFieldValue(fieldValue reflect.Value, value string, timeFo
 		fieldValue.SetInt(intVal)
 		return nil
 
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number := trimBasePrefix(r.stripGrouping(r.unformatNumber(value, tag)), tag.base)
+		uintVal, err := strconv.ParseUint(number, tag.base, fieldValue.Type().Bits())
+		if err != nil && r.sciInts {
+			if f, sciErr := parseScientificInt(number, err); sciErr != nil {
+				err = sciErr
+			} else if f < 0 || fieldValue.OverflowUint(uint64(f)) {
+				err = fmt.Errorf("%s is out of range", number)
+			} else {
+				uintVal, err = uint64(f), nil
+			}
+		}
+		if err != nil {
+			return &CSVError{
+				Field:   fieldNameLower,
+				Value:   value,
+				Type:    "uint",
+				Wrapped: err,
+			}
+		}
+		fieldValue.SetUint(uintVal)
+		return nil
+
 	case reflect.Float32, reflect.Float64:
-		floatVal, err := strconv.ParseFloat(value, 64)
+		// Percentages are scaled in decimal so "7%" reads as exactly 0.07
+		number, scale := r.normalizeFloat(r.unformatNumber(value, tag)), 1.0
+		if tag.percent {
+			if shifted, ok := shiftDecimal(number, -2); ok {
+				number = shifted
+			} else {
+				scale = 100
+			}
+		}
+		floatVal, err := strconv.ParseFloat(number, 64)
 		if err != nil {
 			return &CSVError{
 				Field:   fieldNameLower,
@@ -223,11 +2325,29 @@ func (r *CSVReader) setFieldValue(fieldValue reflect.Value, value string, timeFo
 				Wrapped: err,
 			}
 		}
+		floatVal /= scale
+		if tag.accounting && floatVal == 0 {
+			// (0.00) is zero, not negative zero
+			floatVal = 0
+		}
 		fieldValue.SetFloat(floatVal)
 		return nil
 
+	case reflect.Complex64, reflect.Complex128:
+		complexVal, err := strconv.ParseComplex(value, fieldValue.Type().Bits())
+		if err != nil {
+			return &CSVError{
+				Field:   fieldNameLower,
+				Value:   value,
+				Type:    "complex",
+				Wrapped: err,
+			}
+		}
+		fieldValue.SetComplex(complexVal)
+		return nil
+
 	case reflect.Bool:
-		boolVal, err := parseBool(value)
+		boolVal, err := r.parseBool(value)
 		if err != nil {
 			return &CSVError{
 				Field:   fieldNameLower,
@@ -239,21 +2359,127 @@ func (r *CSVReader) setFieldValue(fieldValue reflect.Value, value string, timeFo
 		fieldValue.SetBool(boolVal)
 		return nil
 
-	default:
-		return &CSVError{
-			Field: fieldNameLower,
-			Value: value,
-			Type:  fieldValue.Kind().String(),
+	case reflect.Slice:
+		if !isSplitSlice(fieldValue) {
+			data, err := decodeBytes(value, tag.encoding)
+			if err != nil {
+				return &CSVError{
+					Field:   fieldNameLower,
+					Value:   value,
+					Type:    "[]byte",
+					Wrapped: err,
+				}
+			}
+			fieldValue.SetBytes(data)
+			return nil
 		}
+		parts := strings.Split(value, r.sliceSeparator())
+		slice := reflect.MakeSlice(fieldValue.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := r.setFieldValue(slice.Index(i), r.trim(part, tag), tag, fieldName); err != nil {
+				return err
+			}
+		}
+		fieldValue.Set(slice)
+		return nil
+
+	case reflect.Array:
+		parts := strings.Split(value, r.sliceSeparator())
+		if len(parts) != fieldValue.Len() {
+			return &CSVError{
+				Field:   fieldNameLower,
+				Value:   value,
+				Type:    fieldValue.Type().String(),
+				Wrapped: fmt.Errorf("got %d elements, want %d", len(parts), fieldValue.Len()),
+			}
+		}
+		// Fill a copy so a failed element leaves the field unchanged
+		array := reflect.New(fieldValue.Type()).Elem()
+		for i, part := range parts {
+			if err := r.setFieldValue(array.Index(i), r.trim(part, tag), tag, fieldName); err != nil {
+				return err
+			}
+		}
+		fieldValue.Set(array)
+		return nil
+	}
+
+	return &CSVError{
+		Field: fieldNameLower,
+		Value: value,
+		Type:  fieldValue.Kind().String(),
+	}
+}
+
+// isSplitSlice reports whether the slice field is filled by splitting a
+// cell on the slice separator. Byte slices are not split.
+func isSplitSlice(fieldValue reflect.Value) bool {
+	return fieldValue.Type().Elem().Kind() != reflect.Uint8
+}
+
+// trimBasePrefix removes a 0x, 0o or 0b prefix matching base, so that
+// base=16 accepts both 1F and 0x1F. Base 0 keeps the prefix, which
+// strconv uses to detect the base.
+func trimBasePrefix(value string, base int) string {
+	var prefix string
+	switch base {
+	case 16:
+		prefix = "0x"
+	case 8:
+		prefix = "0o"
+	case 2:
+		prefix = "0b"
+	default:
+		return value
+	}
+
+	sign := ""
+	if value != "" && (value[0] == '-' || value[0] == '+') {
+		sign, value = value[:1], value[1:]
+	}
+	if len(value) > len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+		value = value[len(prefix):]
+	}
+	return sign + value
+}
+
+// decodeBytes decodes a []byte cell according to the encoding tag option.
+// Without an encoding the cell text is used as is.
+func decodeBytes(value, enc string) ([]byte, error) {
+	switch enc {
+	case "":
+		return []byte(value), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(value)
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", enc)
+}
+
+// encodeBytes is the inverse of decodeBytes
+func encodeBytes(data []byte, enc string) (string, error) {
+	switch enc {
+	case "":
+		return string(data), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+	return "", fmt.Errorf("unsupported encoding %q", enc)
+}
+
+func (r *CSVReader) sliceSeparator() string {
+	if r.sliceSep == "" {
+		return DefaultSliceSeparator
 	}
+	return r.sliceSep
 }
 
 func (r *CSVReader) setTimeValue(fieldValue reflect.Value, value, timeFormat, fieldName string) error {
-	t, err := time.Parse(timeFormat, value)
-	if err != nil {
-		// Coba parse dengan format default jika format custom gagal
-		sanitizedValue, sanitizeErr := r.sanitizeTimeValue(value)
-		if sanitizeErr != nil {
+	var t time.Time
+	var err error
+	if isUnixFormat(timeFormat) {
+		// Epoch timestamps do not fall back to other layouts
+		t, err = r.parseUnixTime(timeFormat, value)
+		if err != nil {
 			return &CSVError{
 				Field:   fieldName,
 				Value:   value,
@@ -261,8 +2487,19 @@ func (r *CSVReader) setTimeValue(fieldValue reflect.Value, value, timeFormat, fi
 				Wrapped: err,
 			}
 		}
-		t, err = time.Parse(r.timeLayout, sanitizedValue)
-		if err != nil {
+	} else if t, err = r.parseTime(timeFormat, value); err != nil {
+		if r.strictTime {
+			return &CSVError{
+				Field:   fieldName,
+				Value:   value,
+				Type:    "time.Time",
+				Wrapped: err,
+			}
+		}
+		// Coba parse dengan format lain jika format custom gagal
+		var fallbackErr error
+		t, fallbackErr = r.parseTimeFallback(value)
+		if fallbackErr != nil {
 			return &CSVError{
 				Field:   fieldName,
 				Value:   value,
@@ -271,10 +2508,141 @@ func (r *CSVReader) setTimeValue(fieldValue reflect.Value, value, timeFormat, fi
 			}
 		}
 	}
-	fieldValue.Set(reflect.ValueOf(t))
+	// Assigning through the address avoids boxing t in an interface
+	if fieldValue.CanAddr() {
+		*fieldValue.Addr().Interface().(*time.Time) = t
+	} else {
+		fieldValue.Set(reflect.ValueOf(t))
+	}
 	return nil
 }
 
+// swapTimeLayout sets the time layout and returns a function restoring the
+// previous one. Plans embed the layout, so they are set aside as well. The
+// caller must hold the lock until the layout is restored.
+func (r *CSVReader) swapTimeLayout(layout string) func() {
+	timeLayout, plans, detected, common := r.timeLayout, r.plans, r.detectedLayout, r.detectedCommon
+	r.timeLayout, r.plans, r.detectedLayout = layout, nil, ""
+	return func() {
+		r.timeLayout, r.plans, r.detectedLayout, r.detectedCommon = timeLayout, plans, detected, common
+	}
+}
+
+// parseTimeFallback parses a value that does not match the field's format,
+// trying the layouts set with SetTimeLayouts and then commonTimeLayouts.
+// The first of the caller's layouts that parses the value always wins, so
+// they are tried in order on every call. A column in a non-default format
+// usually uses the same format on every row, so the common layout that
+// succeeds is remembered and tried first next time; those layouts do not
+// overlap, which makes the shortcut safe.
+func (r *CSVReader) parseTimeFallback(value string) (time.Time, error) {
+	for _, layout := range r.timeLayouts {
+		if t, err := r.parseTimeLayout(layout, false, value); err == nil {
+			r.detectedLayout, r.detectedCommon = layout, false
+			return t, nil
+		}
+	}
+
+	if r.detectedCommon {
+		if t, err := r.parseTimeLayout(r.detectedLayout, true, value); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range commonTimeLayouts {
+		if t, err := r.parseTimeLayout(layout, true, value); err == nil {
+			r.detectedLayout, r.detectedCommon = layout, true
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse time value: %s", value)
+}
+
+// parseTimeLayout parses value with layout. Values matching one of the
+// common layouts are normalized to the default layout before parsing.
+func (r *CSVReader) parseTimeLayout(layout string, common bool, value string) (time.Time, error) {
+	if !common {
+		return r.parseTime(layout, value)
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return r.parseTime(r.timeLayout, t.Format(r.timeLayout))
+}
+
+// DetectedTimeLayout returns the fallback layout that most recently parsed
+// a time value not matching its field's format, or "" if none has. A
+// non-empty result on a file expected to use the default layout hints
+// that the data is in a different format.
+func (r *CSVReader) DetectedTimeLayout() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.detectedLayout
+}
+
+// normalizeFloat strips the thousands separator and converts the decimal
+// separator to '.' so the value can be handed to strconv
+func (r *CSVReader) normalizeFloat(value string) string {
+	value = r.stripGrouping(value)
+	if r.decimalSep != 0 && r.decimalSep != '.' {
+		value = strings.ReplaceAll(value, string(r.decimalSep), ".")
+	}
+	return value
+}
+
+// stripGrouping removes the thousands separator, if one is configured
+func (r *CSVReader) stripGrouping(value string) string {
+	if r.groupSep == 0 {
+		return value
+	}
+	return strings.ReplaceAll(value, string(r.groupSep), "")
+}
+
+// parseTime parses value in the configured location, defaulting to UTC
+func (r *CSVReader) parseTime(layout, value string) (time.Time, error) {
+	loc := r.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.ParseInLocation(layout, value, loc)
+}
+
+// isUnixFormat reports whether format is one of the epoch formats "unix"
+// or "unixmilli" rather than a time layout
+func isUnixFormat(format string) bool {
+	return format == "unix" || format == "unixmilli"
+}
+
+// parseUnixTime parses value as whole seconds or milliseconds since the
+// Unix epoch, as selected by format
+func (r *CSVReader) parseUnixTime(format, value string) (time.Time, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc := r.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if format == "unixmilli" {
+		return time.UnixMilli(n).In(loc), nil
+	}
+	return time.Unix(n, 0).In(loc), nil
+}
+
+// parseBool parses value using the registered bool tokens, if any
+func (r *CSVReader) parseBool(value string) (bool, error) {
+	if r.boolValues == nil {
+		return parseBool(value)
+	}
+	boolVal, ok := r.boolValues[strings.ToLower(value)]
+	if !ok {
+		return false, fmt.Errorf("invalid boolean value: %s", value)
+	}
+	return boolVal, nil
+}
+
 // Tambahkan helper function untuk parsing boolean
 func parseBool(value string) (bool, error) {
 	value = strings.ToLower(value)
@@ -288,31 +2656,31 @@ func parseBool(value string) (bool, error) {
 	}
 }
 
-func (r *CSVReader) sanitizeTimeValue(value string) (string, error) {
-	if value == "" {
-		return "", nil
-	}
+// commonTimeLayouts are tried for time values that match neither the
+// field's format nor the layouts set with SetTimeLayouts
+var commonTimeLayouts = []string{
+	Layout, ANSIC, UnixDate, RubyDate, RFC822, RFC822Z,
+	RFC850, RFC1123, RFC1123Z, RFC3339, RFC3339Nano,
+	Kitchen, Stamp, StampMilli, StampMicro, StampNano,
+	DateTime, DateOnly, TimeOnly,
+}
 
-	commonLayouts := []string{
-		Layout, ANSIC, UnixDate, RubyDate, RFC822, RFC822Z,
-		RFC850, RFC1123, RFC1123Z, RFC3339, RFC3339Nano,
-		Kitchen, Stamp, StampMilli, StampMicro, StampNano,
-		DateTime, DateOnly, TimeOnly,
+// Close closes any decompression or archive readers and then the
+// underlying file or reader
+func (r *CSVReader) Close() error {
+	var errs []error
+	if err := r.closeDecompressor(); err != nil {
+		errs = append(errs, err)
 	}
-
-	for _, layout := range commonLayouts {
-		if t, err := time.Parse(layout, value); err == nil {
-			return t.Format(r.timeLayout), nil
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if err := r.closers[i].Close(); err != nil {
+			errs = append(errs, err)
 		}
 	}
-
-	return "", fmt.Errorf("unable to parse time value: %s", value)
-}
-
-// Close closes the underlying file
-func (r *CSVReader) Close() error {
 	if r.file != nil {
-		return r.file.Close()
+		if err := r.file.Close(); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
@@ -1,12 +1,28 @@
 package gocsv
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"math/big"
+	"net"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"golang.org/x/text/encoding/unicode"
 )
 
 // TestStruct represents a test structure with various field types
@@ -25,6 +41,7 @@ func TestNewCSVReader(t *testing.T) {
 		name        string
 		content     string
 		expectError bool
+		wantErr     error
 	}{
 		{
 			name: "valid csv",
@@ -36,6 +53,7 @@ value1,123,45.67,true,2024-01-01,optional`,
 			name:        "empty file",
 			content:     "",
 			expectError: true,
+			wantErr:     ErrEmptyFile,
 		},
 		{
 			name:        "invalid file path",
@@ -44,163 +62,3052 @@ value1,123,45.67,true,2024-01-01,optional`,
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Create temporary file
-			tmpFile := createTempFile(t, tt.content)
-			defer os.Remove(tmpFile)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create temporary file
+			tmpFile := createTempFile(t, tt.content)
+			defer os.Remove(tmpFile)
+
+			reader, err := NewCSVReader(tmpFile)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if reader == nil {
+				t.Error("expected reader to not be nil")
+				return
+			}
+
+			defer reader.Close()
+		})
+	}
+}
+
+func TestFailedOpenClosesFile(t *testing.T) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("cannot count open file descriptors on this platform")
+	}
+	before := len(fds)
+
+	empty := createTempFile(t, "")
+	defer os.Remove(empty)
+	notGzip := createTempFile(t, "string_field\nvalue\n")
+	defer os.Remove(notGzip)
+
+	for i := 0; i < 50; i++ {
+		if _, err := NewCSVReader(empty); !errors.Is(err, ErrEmptyFile) {
+			t.Fatalf("expected ErrEmptyFile, got %v", err)
+		}
+		if _, err := NewCSVReaderWithOptions(notGzip, WithSkipRows(5)); !errors.Is(err, ErrEmptyFile) {
+			t.Fatalf("expected ErrEmptyFile after skipping every row, got %v", err)
+		}
+		if _, err := NewCSVReaderGzip(notGzip); err == nil {
+			t.Fatal("expected error for a file that is not gzip, got nil")
+		}
+	}
+
+	fds, err = os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatalf("failed to count open file descriptors: %v", err)
+	}
+	if len(fds) > before {
+		t.Errorf("failed opens leaked %d file descriptors", len(fds)-before)
+	}
+}
+
+func TestNewCSVReaderFromReader(t *testing.T) {
+	content := `string_field,int_field
+value1,123`
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "value1" || got.IntField != 123 {
+		t.Errorf("got %+v, want StringField=value1 IntField=123", got)
+	}
+
+	if _, err := NewCSVReaderFromReader(strings.NewReader("")); err == nil {
+		t.Error("expected error for empty input, got nil")
+	}
+}
+
+func TestDelimiter(t *testing.T) {
+	content := "string_field;int_field\nvalue1;123"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content), WithDelimiter(';'))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "value1" || got.IntField != 123 {
+		t.Errorf("got %+v, want StringField=value1 IntField=123", got)
+	}
+
+	if err := reader.SetDelimiter('|'); !errors.Is(err, ErrHeaderAlreadyRead) {
+		t.Errorf("expected ErrHeaderAlreadyRead after the header was read, got %v", err)
+	}
+	for _, delim := range []rune{'\n', '\r', '"'} {
+		if err := reader.SetDelimiter(delim); err == nil {
+			t.Errorf("expected error for delimiter %q, got nil", delim)
+		}
+		if _, err := NewCSVReaderFromReader(strings.NewReader(content), WithDelimiter(delim)); err == nil {
+			t.Errorf("expected option error for delimiter %q, got nil", delim)
+		}
+	}
+}
+
+func TestNoHeader(t *testing.T) {
+	type positional struct {
+		Name  string `csv:"[0]"`
+		Count int    `csv:"2"`
+		Skip  string `csv:"-"`
+	}
+
+	content := "alpha,ignored,1\nbeta,ignored,2"
+	tmpFile := createTempFile(t, content)
+
+	reader, err := NewCSVReaderNoHeader(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var all []positional
+	if err := reader.ReadAll(&all); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []positional{{Name: "alpha", Count: 1}, {Name: "beta", Count: 2}}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("got %+v, want %+v", all, expected)
+	}
+}
+
+func TestCaseInsensitiveHeaders(t *testing.T) {
+	content := "String_Field, int_field \nvalue1,123"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetCaseInsensitiveHeaders(true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "value1" || got.IntField != 123 {
+		t.Errorf("got %+v, want StringField=value1 IntField=123", got)
+	}
+
+	collision, err := NewCSVReaderFromReader(strings.NewReader("name,NAME\na,b"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := collision.SetCaseInsensitiveHeaders(true); err == nil {
+		t.Error("expected collision error, got nil")
+	}
+}
+
+func TestDefaultValues(t *testing.T) {
+	type withDefaults struct {
+		Status string `csv:"status" default:"active"`
+		Count  int    `csv:"count" default:"-1"`
+		Note   string `csv:"note"`
+	}
+
+	content := "status,count,note\n,,\npaused,5,hello"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got []withDefaults
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []withDefaults{
+		{Status: "active", Count: -1},
+		{Status: "paused", Count: 5, Note: "hello"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v, want %+v", got, expected)
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	content := "\ufeffstring_field,int_field\nvalue1,123"
+	tmpFile := createTempFile(t, content)
+
+	reader, err := NewCSVReader(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "value1" || got.IntField != 123 {
+		t.Errorf("got %+v, want StringField=value1 IntField=123", got)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	enc := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	content, err := enc.NewEncoder().String("string_field,int_field\nZoë,7")
+	if err != nil {
+		t.Fatalf("failed to encode content: %v", err)
+	}
+	tmpFile := createTempFile(t, content)
+
+	reader, err := NewCSVReaderWithEncoding(tmpFile, enc)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "Zoë" || got.IntField != 7 {
+		t.Errorf("got %+v, want StringField=Zoë IntField=7", got)
+	}
+}
+
+func TestNewCSVReaderGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("string_field,int_field\nvalue1,123"))
+	gz.Close()
+	tmpFile := createTempFile(t, buf.String())
+
+	reader, err := NewCSVReaderGzip(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "value1" || got.IntField != 123 {
+		t.Errorf("got %+v, want StringField=value1 IntField=123", got)
+	}
+
+	if err := reader.Close(); err != nil {
+		t.Errorf("unexpected close error: %v", err)
+	}
+	if err := reader.file.Close(); err == nil {
+		t.Error("expected file to be closed")
+	}
+
+	if _, err := NewCSVReaderGzip(createTempFile(t, "plain,text")); err == nil {
+		t.Error("expected error for non-gzip file, got nil")
+	}
+}
+
+func TestNewCSVReaderGzipFailedReset(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("string_field,int_field\n")
+	const rows = 20000
+	for i := 1; i <= rows; i++ {
+		fmt.Fprintf(&content, "value%d,%d\n", i, i)
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(content.String()))
+	gz.Close()
+	tmpFile := createTempFile(t, buf.String())
+
+	reader, err := NewCSVReaderGzip(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Overwrite the start of the file, which the gzip reader has already
+	// consumed, with a stream whose header differs
+	buf.Reset()
+	gz = gzip.NewWriter(&buf)
+	gz.Write([]byte("other,columns\n"))
+	gz.Close()
+	file, err := os.OpenFile(tmpFile, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	file.WriteAt(buf.Bytes(), 0)
+	file.Close()
+
+	if err := reader.Reset(); err == nil {
+		t.Fatal("expected error for changed header, got nil")
+	}
+
+	// Reading carries on from the second row to the end
+	var rest []TestStruct
+	if err := reader.ReadAll(&rest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rest) != rows-1 || rest[0].IntField != 2 || rest[len(rest)-1].IntField != rows {
+		t.Errorf("expected rows 2 to %d, got %d rows", rows, len(rest))
+	}
+}
+
+func TestNewCSVReaderFromZip(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "batch.zip")
+	file, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	zw := zip.NewWriter(file)
+	for name, content := range map[string]string{
+		"orders.csv":    "string_field,int_field\norder,1",
+		"data/rows.csv": "string_field,int_field\nvalue1,123\nvalue2,456",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to write zip: %v", err)
+	}
+	file.Close()
+
+	reader, err := NewCSVReaderFromZip(zipPath, "data/rows.csv")
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		var got []TestStruct
+		if err := reader.ReadAll(&got); err != nil {
+			t.Fatalf("pass %d: unexpected error: %v", pass, err)
+		}
+		if len(got) != 2 || got[0].StringField != "value1" || got[1].IntField != 456 {
+			t.Errorf("pass %d: unexpected rows: %+v", pass, got)
+		}
+		if err := reader.Reset(); err != nil {
+			t.Fatalf("pass %d: failed to reset: %v", pass, err)
+		}
+	}
+
+	if err := reader.Close(); err != nil {
+		t.Errorf("unexpected close error: %v", err)
+	}
+	if err := reader.closers[0].Close(); err == nil {
+		t.Error("expected the archive to be closed")
+	}
+
+	if _, err := NewCSVReaderFromZip(zipPath, "missing.csv"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist for a missing member, got %v", err)
+	}
+	if _, err := NewCSVReaderFromZip(createTempFile(t, "plain,text"), "rows.csv"); err == nil {
+		t.Error("expected error for a file that is not a zip archive, got nil")
+	}
+}
+
+func TestNewCSVReaderBytes(t *testing.T) {
+	reader, err := NewCSVReaderBytes([]byte("string_field;int_field\nvalue1;1"), WithDelimiter(';'))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		var got TestStruct
+		if err := reader.ReadNext(&got); err != nil {
+			t.Fatalf("pass %d: unexpected error: %v", pass, err)
+		}
+		if got.StringField != "value1" || got.IntField != 1 {
+			t.Errorf("pass %d: unexpected value: %+v", pass, got)
+		}
+		if err := reader.Reset(); err != nil {
+			t.Fatalf("pass %d: failed to reset: %v", pass, err)
+		}
+	}
+	if err := reader.Close(); err != nil {
+		t.Errorf("unexpected error from Close: %v", err)
+	}
+
+	if _, err := NewCSVReaderBytes(nil); err == nil {
+		t.Error("expected error for empty data, got nil")
+	}
+}
+
+func TestSkipRows(t *testing.T) {
+	content := "\ufeffMonthly Report\nGenerated: 2024-01-31\nstring_field,int_field\nvalue1,1\nvalue2,x"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content), WithSkipRows(2))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "value1" || got.IntField != 1 {
+		t.Errorf("got %+v, want StringField=value1 IntField=1", got)
+	}
+
+	err = reader.ReadNext(&got)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Line != 5 {
+		t.Errorf("expected error on line 5, got %v", err)
+	}
+
+	if _, err := NewCSVReaderFromReader(strings.NewReader("title\n"), WithSkipRows(3)); !errors.Is(err, ErrEmptyFile) {
+		t.Errorf("expected ErrEmptyFile when skipping past the end, got %v", err)
+	}
+}
+
+func TestComment(t *testing.T) {
+	content := "# exported 2024-01-31\nstring_field,int_field\nvalue1,1\n# subtotal\nvalue2,2"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content), WithComment('#'))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got []TestStruct
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].StringField != "value1" || got[1].IntField != 2 {
+		t.Errorf("unexpected rows: %+v", got)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("string_field,int_field\n;value1,1\nvalue2,2"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetComment(';'); !errors.Is(err, ErrHeaderAlreadyRead) {
+		t.Errorf("expected ErrHeaderAlreadyRead after the header was read, got %v", err)
+	}
+
+	type positional struct {
+		Name string `csv:"0"`
+	}
+	reader, err = NewCSVReaderFromReader(strings.NewReader(";value1,1\nvalue2,2"), WithNoHeader())
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetComment(';'); err != nil {
+		t.Fatalf("unexpected error without a header: %v", err)
+	}
+	var row positional
+	if err := reader.ReadNext(&row); err != nil || row.Name != "value2" {
+		t.Errorf("got %+v, %v; want Name=value2", row, err)
+	}
+
+	if err := reader.SetComment(','); err == nil {
+		t.Error("expected error for comment equal to delimiter, got nil")
+	}
+	if _, err := NewCSVReaderFromReader(strings.NewReader(content), WithComment('\n')); err == nil {
+		t.Error("expected error for newline comment, got nil")
+	}
+}
+
+func TestLazyQuotes(t *testing.T) {
+	content := "string_field,int_field\n5\" screen,1\nsay \"hi\",2"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var row TestStruct
+	if err := reader.ReadNext(&row); err == nil {
+		t.Error("expected parse error without lazy quotes, got nil")
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetLazyQuotes(true)
+	var got []TestStruct
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].StringField != `5" screen` || got[1].StringField != `say "hi"` {
+		t.Errorf("unexpected rows: %+v", got)
+	}
+
+	header := "string_field,int\"field\nvalue1,1"
+	if _, err := NewCSVReaderFromReader(strings.NewReader(header)); err == nil {
+		t.Error("expected header parse error without lazy quotes, got nil")
+	}
+	reader, err = NewCSVReaderFromReader(strings.NewReader(header), WithLazyQuotes())
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if !reader.HasColumn(`int"field`) {
+		t.Errorf("headers = %v, want int\"field column", reader.Headers())
+	}
+}
+
+func TestParseCSVTag(t *testing.T) {
+	tests := []struct {
+		tag      reflect.StructTag
+		expected csvTag
+	}{
+		{``, csvTag{name: "Field", timeFormat: DateOnly, base: 10}},
+		{`csv:"date"`, csvTag{name: "date", timeFormat: DateOnly, base: 10}},
+		{`csv:"date,02/01/2006"`, csvTag{name: "date", timeFormat: "02/01/2006", base: 10}},
+		{`csv:"date,,required"`, csvTag{name: "date", timeFormat: DateOnly, base: 10, required: true}},
+		{`csv:"date,02/01/2006,required"`, csvTag{name: "date", timeFormat: "02/01/2006", base: 10, required: true}},
+		{`csv:"date,format=02/01/2006"`, csvTag{name: "date", timeFormat: "02/01/2006", base: 10}},
+		{`csv:"name,omitempty,required"`, csvTag{name: "name", timeFormat: DateOnly, base: 10, required: true, omitEmpty: true}},
+		{`csv:"qty,required,default=1"`, csvTag{name: "qty", timeFormat: DateOnly, base: 10, defaultValue: "1", hasDefault: true, required: true}},
+		{`csv:"qty,default=" default:"1"`, csvTag{name: "qty", timeFormat: DateOnly, base: 10, hasDefault: true}},
+		{`csv:"qty" default:"1"`, csvTag{name: "qty", timeFormat: DateOnly, base: 10, defaultValue: "1", hasDefault: true}},
+		{`csv:",omitempty"`, csvTag{name: "Field", timeFormat: DateOnly, base: 10, omitEmpty: true}},
+		{`csv:"reg,base=16"`, csvTag{name: "reg", timeFormat: DateOnly, base: 16}},
+		{`csv:"reg,base=hex"`, csvTag{name: "reg", timeFormat: DateOnly, base: -1}},
+		{`csv:"status,oneof=new paid  refunded,required"`, csvTag{name: "status", timeFormat: DateOnly, base: 10, required: true, oneOf: []string{"new", "paid", "refunded"}}},
+		{`csv:"age,,min=0,max=120"`, csvTag{name: "age", timeFormat: DateOnly, base: 10, min: 0, max: 120, hasMin: true, hasMax: true}},
+		{`csv:"code,,transform=trim upper"`, csvTag{name: "code", timeFormat: DateOnly, base: 10, transforms: []string{"trim", "upper"}}},
+		{`csv:"email,,required,msg=Email is mandatory"`, csvTag{name: "email", timeFormat: DateOnly, base: 10, required: true, message: "Email is mandatory"}},
+		{`csv:"email,msg=Please, fill in the email,required"`, csvTag{name: "email", timeFormat: DateOnly, base: 10, message: "Please, fill in the email,required"}},
+		{`csv:"amount,setter=SetAmount"`, csvTag{name: "amount", timeFormat: DateOnly, base: 10, setter: "SetAmount"}},
+	}
+
+	for _, tt := range tests {
+		field := reflect.StructField{Name: "Field", Tag: tt.tag}
+		if got := parseCSVTag(field, DateOnly); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseCSVTag(%s) = %+v, want %+v", tt.tag, got, tt.expected)
+		}
+	}
+}
+
+func TestDialect(t *testing.T) {
+	type note struct {
+		Name string `csv:"name"`
+		Note string `csv:"note"`
+	}
+
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		content  string
+		expected []note
+	}{
+		{
+			name:    "single quotes",
+			dialect: Dialect{Delimiter: ';', Quote: '\''},
+			content: "name;note\n'alice';'it''s; \"fine\"'\n'multi\nline';x\n",
+			expected: []note{
+				{Name: "alice", Note: `it's; "fine"`},
+				{Name: "multi\nline", Note: "x"},
+			},
+		},
+		{
+			name:     "backslash escape",
+			dialect:  Dialect{Escape: '\\'},
+			content:  "name,note\n\"bob\",\"say \\\"hi\\\", ok\"\n",
+			expected: []note{{Name: "bob", Note: `say "hi", ok`}},
+		},
+		{
+			name:     "comment containing the quote",
+			dialect:  Dialect{Quote: '\'', Comment: '#'},
+			content:  "# it's a comment, 'x\nname,note\n# another 'one\ncarol,'a,b'\n",
+			expected: []note{{Name: "carol", Note: "a,b"}},
+		},
+		{
+			name:     "native settings only",
+			dialect:  Dialect{Delimiter: '\t', Comment: '#', LazyQuotes: true},
+			content:  "name\tnote\n#skip\ndave\t5\" screen\n",
+			expected: []note{{Name: "dave", Note: `5" screen`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewCSVReaderFromReader(strings.NewReader(tt.content), WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("failed to create reader: %v", err)
+			}
+			var got []note
+			if err := reader.ReadAll(&got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,note\n'open,x"), WithDialect(Dialect{Quote: '\''}))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var n note
+	if err := reader.ReadNext(&n); err == nil || IsEOF(err) {
+		t.Errorf("expected error for unterminated quote, got %v", err)
+	}
+	if err := reader.SetDialect(Dialect{Quote: '\'', LazyQuotes: true}); err != nil {
+		t.Errorf("unexpected error enabling lazy quotes: %v", err)
+	}
+	if err := reader.SetDialect(Dialect{Delimiter: ';', Quote: '\''}); !errors.Is(err, ErrHeaderAlreadyRead) {
+		t.Errorf("expected ErrHeaderAlreadyRead changing the delimiter, got %v", err)
+	}
+	if err := reader.SetDialect(Dialect{Delimiter: ';'}); err == nil {
+		t.Error("expected error changing the quote after construction, got nil")
+	}
+
+	for _, d := range []Dialect{{Quote: ','}, {Quote: '\n'}, {Escape: '"'}, {Delimiter: '#', Comment: '#'}} {
+		if _, err := NewCSVReaderFromReader(strings.NewReader("a\n1"), WithDialect(d)); err == nil {
+			t.Errorf("expected error for dialect %+v, got nil", d)
+		}
+	}
+}
+
+func TestValidateHeaders(t *testing.T) {
+	type contact struct {
+		Name  string `csv:"name,,required"`
+		Email string `csv:"email,,required"`
+		Phone string `csv:"phone,,required"`
+		Notes string `csv:"notes"`
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,notes\nalice,hi"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	err = reader.ValidateHeaders(contact{})
+	csvErr, ok := err.(*CSVError)
+	if !ok {
+		t.Fatalf("expected *CSVError, got %T: %v", err, err)
+	}
+	if csvErr.Value != "email,phone" {
+		t.Errorf("expected missing email,phone, got %q", csvErr.Value)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("phone,email,name\n1,a@b.c,alice"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.ValidateHeaders(&contact{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRequiredEmptyCells(t *testing.T) {
+	type signup struct {
+		Name  string `csv:"name,,required"`
+		Email string `csv:"email,,required,msg=Email is mandatory"`
+		Plan  string `csv:"plan,required,default=free"`
+	}
+
+	content := "name,email,plan\nalice,a@b.c,\nbob,,pro\n,c@d.e,\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got signup
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Plan != "free" {
+		t.Errorf("expected the default to satisfy required, got %+v", got)
+	}
+
+	err = reader.ReadNext(&got)
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || csvErr.Field != "email" || csvErr.Line != 3 {
+		t.Fatalf("expected a CSVError for email on line 3, got %v", err)
+	}
+	if got := err.Error(); got != "line 3: field email: Email is mandatory" {
+		t.Errorf("expected the custom message, got %q", got)
+	}
+
+	err = reader.ReadNext(&got)
+	if !errors.As(err, &csvErr) || err.Error() != "line 4: field name: empty value not allowed" {
+		t.Errorf("expected the default message for name, got %v", err)
+	}
+}
+
+func TestExpectHeaders(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("id,Name,email\n1,alice,a@b.c"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	if err := reader.ExpectHeaders([]string{"id", "Name", "email"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		expected []string
+		message  string
+	}{
+		{[]string{"id", "email", "Name"}, `column 2: expected "email", got "Name"`},
+		{[]string{"id", "Name"}, `column 3: unexpected extra column "email"`},
+		{[]string{"id", "Name", "email", "phone"}, `column 4: expected "phone", header ends after 3 columns`},
+		{[]string{"id", "name", "email"}, `column 2: expected "name", got "Name"`},
+	}
+	for _, tt := range tests {
+		err := reader.ExpectHeaders(tt.expected)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("ExpectHeaders(%v) = %v, want error containing %q", tt.expected, err, tt.message)
+		}
+	}
+
+	if err := reader.SetCaseInsensitiveHeaders(true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := reader.ExpectHeaders([]string{"ID", "name", "Email"}); err != nil {
+		t.Errorf("unexpected error with case-insensitive headers: %v", err)
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("string_field,int_field\nvalue1,1\nvalue2,2"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	if err := reader.Prepare(TestStruct{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := reader.plans[reflect.TypeOf(TestStruct{})]; !ok {
+		t.Error("expected a cached plan after Prepare")
+	}
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil || got.IntField != 1 {
+		t.Fatalf("got %+v, %v; want IntField=1", got, err)
+	}
+
+	// Changing the mapping must not reuse the stale plan
+	reader.SetColumnMapping(map[string]string{"IntField": "string_field"})
+	if err := reader.ReadNext(&got); err == nil {
+		t.Error("expected error after remapping IntField to a string column, got nil")
+	}
+
+	reader.StrictColumns(true)
+	if err := reader.Prepare(&TestStruct{}); err == nil {
+		t.Error("expected StrictColumns error from Prepare, got nil")
+	}
+	if err := reader.Prepare(42); err == nil {
+		t.Error("expected error for non-struct prototype, got nil")
+	}
+}
+
+func TestSelectColumns(t *testing.T) {
+	content := "string_field,int_field,float_field,extra\na,1,1.5,x\nb,2,2.5,y\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	if err := reader.SelectColumns("string_field", "email"); !errors.Is(err, ErrColumnMissing) {
+		t.Errorf("expected ErrColumnMissing for an absent column, got %v", err)
+	}
+	if err := reader.SelectColumns("string_field", "float_field"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := TestStruct{IntField: 42}
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "a" || got.FloatField != 1.5 || got.IntField != 42 {
+		t.Errorf("expected only selected columns to be decoded, got %+v", got)
+	}
+
+	row, err := reader.ReadNextMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(row, map[string]string{"string_field": "b", "float_field": "2.5"}) {
+		t.Errorf("unexpected map row: %v", row)
+	}
+
+	if err := reader.SelectColumns(); err != nil {
+		t.Fatalf("unexpected error clearing the selection: %v", err)
+	}
+	if err := reader.Reset(); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.IntField != 1 {
+		t.Errorf("expected every column after clearing the selection, got %+v", got)
+	}
+}
+
+func TestSetColumnMapping(t *testing.T) {
+	content := "Full Name,E-mail,ignored\nalice,a@b.c,x"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	mapping := map[string]string{"StringField": "Full Name", "OptionalPtr": "E-mail"}
+	reader.SetColumnMapping(mapping)
+	mapping["StringField"] = "ignored"
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "alice" || got.OptionalPtr == nil || *got.OptionalPtr != "a@b.c" {
+		t.Errorf("unexpected value: %+v", got)
+	}
+
+	type customer struct {
+		Name    string  `csv:"name"`
+		Address Address `csv:"address"`
+	}
+	reader, err = NewCSVReaderFromReader(strings.NewReader("name,home.city,home.zip\nbob,Paris,75001"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetColumnMapping(map[string]string{"Address": "home"})
+	if err := reader.ValidateHeaders(customer{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var c customer
+	if err := reader.ReadNext(&c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Address.City != "Paris" || c.Address.Zip != "75001" {
+		t.Errorf("unexpected value: %+v", c)
+	}
+}
+
+func TestRowIndex(t *testing.T) {
+	content := "title\nstring_field,int_field\na,1\nb,x\nc,3\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content), WithSkipRows(1))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if reader.RowIndex() != 0 {
+		t.Errorf("expected 0 before the first row, got %d", reader.RowIndex())
+	}
+
+	var row TestStruct
+	if err := reader.ReadNext(&row); err != nil || reader.RowIndex() != 1 {
+		t.Fatalf("expected row 1, got %d (%v)", reader.RowIndex(), err)
+	}
+	err = reader.ReadNext(&row)
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || csvErr.Row != reader.RowIndex() || reader.RowIndex() != 2 {
+		t.Errorf("expected the failed row to be counted as row 2, got %d (%v)", reader.RowIndex(), err)
+	}
+	if err := reader.ReadNext(&row); err != nil || reader.RowIndex() != 3 {
+		t.Errorf("expected row 3, got %d (%v)", reader.RowIndex(), err)
+	}
+	if err := reader.ReadNext(&row); err != io.EOF || reader.RowIndex() != 3 {
+		t.Errorf("expected EOF to leave the index at 3, got %d (%v)", reader.RowIndex(), err)
+	}
+}
+
+func TestReadNextRaw(t *testing.T) {
+	content := "name;note\n Alice ;a;extra\nBob;b\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content), WithDelimiter(';'))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetVariableColumns(true)
+
+	if headers := reader.Headers(); !reflect.DeepEqual(headers, []string{"name", "note"}) {
+		t.Errorf("unexpected headers: %v", headers)
+	}
+	first, err := reader.ReadNextRaw()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := reader.ReadNextRaw()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(first, []string{" Alice ", "a", "extra"}) {
+		t.Errorf("first record was changed or trimmed: %q", first)
+	}
+	if !reflect.DeepEqual(second, []string{"Bob", "b"}) {
+		t.Errorf("unexpected second record: %q", second)
+	}
+	if _, err := reader.ReadNextRaw(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestReadNextMap(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name, city \n alice ,Paris\nbob,\"Lyon,\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	row, err := reader.ReadNextMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"name": "alice", " city ": "Paris"}
+	if !reflect.DeepEqual(row, expected) {
+		t.Errorf("got %v, want %v", row, expected)
+	}
+
+	if _, err := reader.ReadNextMap(); err == nil || IsEOF(err) {
+		t.Errorf("expected malformed record error, got %v", err)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("a,b\n1,2\n3,4"), WithNoHeader())
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	rows, err := reader.ReadAllMaps()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 3 || rows[2]["0"] != "3" || rows[2]["1"] != "4" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	if _, err := reader.ReadNextMap(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestStrictColumns(t *testing.T) {
+	type contact struct {
+		Name     string `csv:"name"`
+		Email    string `csv:"email"`
+		Internal string `csv:"-"`
+		Address  Address
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,Address.city\nalice,Paris"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got contact
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error without strict columns: %v", err)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("name,Address.city\nalice,Paris"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.StrictColumns(true)
+	err = reader.ReadNext(&got)
+	csvErr, ok := err.(*CSVError)
+	if !ok {
+		t.Fatalf("expected *CSVError, got %T: %v", err, err)
+	}
+	// The header is at fault, so no row is consumed or named
+	if csvErr.Value != "email,Address.zip" || csvErr.Line != 0 {
+		t.Errorf("got missing %q on line %d, want email,Address.zip with no line", csvErr.Value, csvErr.Line)
+	}
+	reader.StrictColumns(false)
+	if err := reader.ReadNext(&got); err != nil || got.Name != "alice" {
+		t.Errorf("expected the first row after the strict error, got %+v (err %v)", got, err)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("email,name,Address.zip,Address.city\na@b.c,alice,75001,Paris"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.StrictColumns(true)
+	if err := reader.ReadNext(&got); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// AuditFields is embedded in row types to test embedded struct support
+type AuditFields struct {
+	CreatedAt time.Time `csv:"created_at"`
+	UpdatedBy string    `csv:"updated_by"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	type account struct {
+		Name string `csv:"name"`
+		AuditFields
+	}
+	type accountPtr struct {
+		Name string `csv:"name"`
+		*AuditFields
+	}
+
+	content := "name,created_at,updated_by\nalice,2024-01-01,bob"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var got account
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "alice" || got.UpdatedBy != "bob" || !got.CreatedAt.Equal(mustParseTime("2024-01-01")) {
+		t.Errorf("unexpected value: %+v", got)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var gotPtr accountPtr
+	if err := reader.ReadNext(&gotPtr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotPtr.AuditFields == nil || gotPtr.UpdatedBy != "bob" {
+		t.Errorf("unexpected value: %+v", gotPtr)
+	}
+}
+
+// Address is used as a named nested struct in tests
+type Address struct {
+	City string `csv:"city"`
+	Zip  string `csv:"zip,,required"`
+}
+
+func TestNestedStructs(t *testing.T) {
+	type customer struct {
+		Name     string   `csv:"name"`
+		Address  Address  `csv:"address"`
+		Shipping *Address `csv:"shipping"`
+	}
+
+	content := "name,address.city,address.zip,shipping.city,shipping.zip\nalice,Paris,75001,Lyon,69001"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.ValidateHeaders(customer{}); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	var got customer
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := customer{
+		Name:     "alice",
+		Address:  Address{City: "Paris", Zip: "75001"},
+		Shipping: &Address{City: "Lyon", Zip: "69001"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %+v, want %+v", got, expected)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("name,address.city\nbob,Rome"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	err = reader.ValidateHeaders(customer{})
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Value != "address.zip,shipping.zip" {
+		t.Errorf("expected missing nested columns, got %v", err)
+	}
+
+	// A nested pointer with none of its columns in the header stays nil
+	var partial customer
+	if err := reader.ReadNext(&partial); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if partial.Address.City != "Rome" || partial.Shipping != nil {
+		t.Errorf("expected a nil Shipping, got %+v", partial)
+	}
+}
+
+type treeNode struct {
+	Name string    `csv:"name"`
+	Next *treeNode // self-referential, skipped
+	Leaf *treeLeaf `csv:"leaf"`
+}
+
+type treeLeaf struct {
+	Value int       `csv:"value"`
+	Root  *treeNode // refers back to an enclosing type, skipped
+}
+
+func TestRecursiveStructs(t *testing.T) {
+	content := "name,leaf.value\nroot,1\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.StrictColumns(true)
+	if err := reader.Prepare(treeNode{}); err != nil {
+		t.Fatalf("unexpected error from Prepare: %v", err)
+	}
+
+	var got treeNode
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "root" || got.Next != nil || got.Leaf == nil || got.Leaf.Value != 1 || got.Leaf.Root != nil {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
+
+func TestSetEmptyAsError(t *testing.T) {
+	content := "string_field,int_field\nvalue1,\n,2"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetEmptyAsError("string_field", "float_field")
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Errorf("unexpected error for empty optional column: %v", err)
+	}
+
+	err = reader.ReadNext(&got)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Field != "string_field" || csvErr.Line != 3 {
+		t.Errorf("expected empty string_field error on line 3, got %v", err)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("Name,Email\nalice,a@b.c"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	headers := reader.Headers()
+	if !reflect.DeepEqual(headers, []string{"Name", "Email"}) {
+		t.Errorf("unexpected headers: %v", headers)
+	}
+	headers[0] = "changed"
+	if reader.Headers()[0] != "Name" {
+		t.Error("Headers should return a copy")
+	}
+
+	if !reader.HasColumn("Email") || reader.HasColumn("email") {
+		t.Error("unexpected case-sensitive HasColumn result")
+	}
+	if err := reader.SetCaseInsensitiveHeaders(true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reader.HasColumn(" email ") || reader.HasColumn("phone") {
+		t.Error("unexpected case-insensitive HasColumn result")
+	}
+}
+
+func TestSetNullValues(t *testing.T) {
+	content := "string_field,int_field,optional_field\nNULL,n/a,\\N\nvalue,1,null"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetNullValues("NULL", "N/A", "\\N")
+
+	var got []TestStruct
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].StringField != "" || got[0].IntField != 0 || got[0].OptionalPtr != nil {
+		t.Errorf("expected zero values, got %+v", got[0])
+	}
+	if got[1].StringField != "value" || got[1].IntField != 1 || got[1].OptionalPtr != nil {
+		t.Errorf("unexpected second row: %+v", got[1])
+	}
+}
+
+func TestReadNext(t *testing.T) {
+	content := `string_field,int_field,float_field,bool_field,date_field,optional_field
+value1,123,45.67,true,2024-01-01,optional
+value2,-456,78.90,false,2024-02-01,
+value3,789,12.34,yes,2024-03-01,test`
+
+	tmpFile := createTempFile(t, content)
+	defer os.Remove(tmpFile)
+
+	reader, err := NewCSVReader(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	expected := []TestStruct{
+		{
+			StringField: "value1",
+			IntField:    123,
+			FloatField:  45.67,
+			BoolField:   true,
+			DateField:   mustParseTime("2024-01-01"),
+			OptionalPtr: strPtr("optional"),
+		},
+		{
+			StringField: "value2",
+			IntField:    -456,
+			FloatField:  78.90,
+			BoolField:   false,
+			DateField:   mustParseTime("2024-02-01"),
+			OptionalPtr: nil,
+		},
+		{
+			StringField: "value3",
+			IntField:    789,
+			FloatField:  12.34,
+			BoolField:   true,
+			DateField:   mustParseTime("2024-03-01"),
+			OptionalPtr: strPtr("test"),
+		},
+	}
+
+	for i, exp := range expected {
+		var got TestStruct
+		err := reader.ReadNext(&got)
+		if err != nil {
+			t.Errorf("row %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		if got.StringField != exp.StringField {
+			t.Errorf("row %d: StringField: got %v, want %v", i, got.StringField, exp.StringField)
+		}
+		if got.IntField != exp.IntField {
+			t.Errorf("row %d: IntField: got %v, want %v", i, got.IntField, exp.IntField)
+		}
+		if got.FloatField != exp.FloatField {
+			t.Errorf("row %d: FloatField: got %v, want %v", i, got.FloatField, exp.FloatField)
+		}
+		if got.BoolField != exp.BoolField {
+			t.Errorf("row %d: BoolField: got %v, want %v", i, got.BoolField, exp.BoolField)
+		}
+		if !got.DateField.Equal(exp.DateField) {
+			t.Errorf("row %d: DateField: got %v, want %v", i, got.DateField, exp.DateField)
+		}
+		if (got.OptionalPtr == nil) != (exp.OptionalPtr == nil) {
+			t.Errorf("row %d: OptionalPtr: got %v, want %v", i, got.OptionalPtr, exp.OptionalPtr)
+		} else if got.OptionalPtr != nil && *got.OptionalPtr != *exp.OptionalPtr {
+			t.Errorf("row %d: OptionalPtr value: got %v, want %v", i, *got.OptionalPtr, *exp.OptionalPtr)
+		}
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	content := `string_field,int_field,float_field,bool_field,date_field,optional_field
+value1,123,45.67,true,2024-01-01,optional
+value2,-456,78.90,false,2024-02-01,`
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got []TestStruct
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(got))
+	}
+	if got[0].StringField != "value1" || got[1].IntField != -456 {
+		t.Errorf("unexpected rows: %+v", got)
+	}
+	if got[1].OptionalPtr != nil {
+		t.Errorf("expected nil OptionalPtr, got %v", *got[1].OptionalPtr)
+	}
+}
+
+func TestReadAllErrors(t *testing.T) {
+	content := `string_field,int_field
+value1,123
+value2,abc`
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got []TestStruct
+	err = reader.ReadAll(&got)
+	csvErr, ok := err.(*CSVError)
+	if !ok {
+		t.Fatalf("expected *CSVError, got %T: %v", err, err)
+	}
+	if csvErr.Row != 2 {
+		t.Errorf("expected error on row 2, got row %d", csvErr.Row)
+	}
+
+	var notSlice TestStruct
+	if err := reader.ReadAll(&notSlice); err == nil {
+		t.Error("expected error for non-slice destination, got nil")
+	}
+}
+
+func TestUnsignedFields(t *testing.T) {
+	type uintStruct struct {
+		ID    uint64 `csv:"id"`
+		Small uint8  `csv:"small"`
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		expected    uintStruct
+		expectError bool
+	}{
+		{
+			name:     "valid values",
+			content:  "id,small\n18446744073709551615,255",
+			expected: uintStruct{ID: 18446744073709551615, Small: 255},
+		},
+		{
+			name:        "negative value",
+			content:     "id,small\n-1,0",
+			expectError: true,
+		},
+		{
+			name:        "overflow",
+			content:     "id,small\n1,256",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewCSVReaderFromReader(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("failed to create reader: %v", err)
+			}
+
+			var got uintStruct
+			err = reader.ReadNext(&got)
+			if tt.expectError {
+				csvErr, ok := err.(*CSVError)
+				if !ok || csvErr.Type != "uint" {
+					t.Errorf("expected uint CSVError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenericHelpers(t *testing.T) {
+	content := "string_field,int_field\nvalue1,1\nvalue2,2\nvalue3,3"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	first, err := ReadOne[TestStruct](reader)
+	if err != nil || first.StringField != "value1" {
+		t.Errorf("unexpected first row: %+v (err %v)", first, err)
+	}
+
+	rest, err := ReadAllInto[TestStruct](reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rest) != 2 || rest[1].IntField != 3 {
+		t.Errorf("unexpected rows: %+v", rest)
+	}
+
+	if _, err := ReadOne[TestStruct](reader); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if _, err := ReadAllInto[int](reader); err == nil {
+		t.Error("expected error for non-struct type, got nil")
+	}
+}
+
+func TestReadAllLenient(t *testing.T) {
+	content := `string_field,int_field
+value1,1
+value2,abc
+value3,3
+value4,4,extra
+value5,x`
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got []TestStruct
+	rowErrors, err := reader.ReadAllLenient(&got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 || got[0].IntField != 1 || got[1].IntField != 3 {
+		t.Errorf("unexpected rows: %+v", got)
+	}
+
+	expectedLines := []int{3, 5, 6}
+	if len(rowErrors) != len(expectedLines) {
+		t.Fatalf("expected %d row errors, got %d: %v", len(expectedLines), len(rowErrors), rowErrors)
+	}
+	for i, line := range expectedLines {
+		if rowErrors[i].Line != line {
+			t.Errorf("row error %d: expected line %d, got %d", i, line, rowErrors[i].Line)
+		}
+	}
+}
+
+func TestReadAllLenientFieldCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		noHeader bool
+	}{
+		{"too few", "string_field,int_field\nvalue1,1\nvalue2\nvalue3,3\n", false},
+		{"too many", "string_field,int_field\nvalue1,1\nvalue2,2,extra\nvalue3,3\n", false},
+		{"no header", "value1,1\nvalue2,2,extra\nvalue3,3\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.noHeader {
+				opts = append(opts, WithNoHeader())
+			}
+			reader, err := NewCSVReaderFromReader(strings.NewReader(tt.content), opts...)
+			if err != nil {
+				t.Fatalf("failed to create reader: %v", err)
+			}
+
+			var got []struct {
+				StringField string `csv:"0"`
+				IntField    int    `csv:"1"`
+			}
+			if !tt.noHeader {
+				reader.SetColumnMapping(map[string]string{"StringField": "string_field", "IntField": "int_field"})
+			}
+			rowErrors, err := reader.ReadAllLenient(&got)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != 2 || got[0].StringField != "value1" || got[1].IntField != 3 {
+				t.Errorf("expected the rows around the bad one, got %+v", got)
+			}
+
+			line := 3
+			if tt.noHeader {
+				line = 2
+			}
+			if len(rowErrors) != 1 || rowErrors[0].Line != line || !errors.Is(rowErrors[0], csv.ErrFieldCount) {
+				t.Fatalf("expected one field count error on line %d, got %v", line, rowErrors)
+			}
+
+			// The field count is enforced again after the lenient read
+			if reader.reader.FieldsPerRecord != 2 {
+				t.Errorf("expected FieldsPerRecord to be restored to 2, got %d", reader.reader.FieldsPerRecord)
+			}
+		})
+	}
+}
+
+func TestJoinRowErrors(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("string_field,int_field\nvalue1,abc\nvalue2,2,extra"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got []TestStruct
+	rowErrors, err := reader.ReadAllLenient(&got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	joined := JoinRowErrors(rowErrors)
+	var multi *MultiError
+	if !errors.As(joined, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("expected *MultiError with 2 errors, got %v", joined)
+	}
+	var csvErr *CSVError
+	if !errors.As(joined, &csvErr) || csvErr.Type != "int" {
+		t.Errorf("expected errors.As to find the int CSVError, got %v", csvErr)
+	}
+	// Each line is named once, not again by RowError
+	want := "line 2: field intfield: error converting value 'abc' to int: strconv.ParseInt: parsing \"abc\": invalid syntax\n" +
+		"line 3: field record: wrong number of fields: got 3 fields, want 2"
+	if got := joined.Error(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if !errors.Is(joined, csv.ErrFieldCount) {
+		t.Error("expected errors.Is to match csv.ErrFieldCount")
+	}
+
+	if JoinRowErrors(nil) != nil {
+		t.Error("expected nil for no row errors")
+	}
+}
+
+func TestCSVErrorUnwrap(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("int_field,float_field\nabc,1\n99999999999999999999,1"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got TestStruct
+	err = reader.ReadNext(&got)
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("expected errors.Is to match strconv.ErrSyntax, got %v", err)
+	}
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) || numErr.Num != "abc" {
+		t.Errorf("expected errors.As to find *strconv.NumError, got %v", err)
+	}
+
+	err = reader.ReadNext(&got)
+	if !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("expected errors.Is to match strconv.ErrRange, got %v", err)
+	}
+
+	if errors.Unwrap(&CSVError{Field: "x"}) != nil {
+		t.Error("expected nil from Unwrap without a wrapped error")
+	}
+}
+
+func TestNoMappableFields(t *testing.T) {
+	type hidden struct {
+		name string
+		Age  int `csv:"-"`
+	}
+	type outer struct {
+		Inner hidden `csv:"inner"`
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,age\nAlice,30\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	if err := reader.Prepare(hidden{}); !errors.Is(err, ErrNoMappableFields) {
+		t.Errorf("expected ErrNoMappableFields from Prepare, got %v", err)
+	}
+	var rows []outer
+	if _, err := reader.ReadAllLenient(&rows); !errors.Is(err, ErrNoMappableFields) {
+		t.Errorf("expected ErrNoMappableFields for a nested struct, got %v", err)
+	}
+	var row hidden
+	err = reader.ReadNext(&row)
+	if !errors.Is(err, ErrNoMappableFields) {
+		t.Errorf("expected ErrNoMappableFields from ReadNext, got %v", err)
+	}
+	want := `field destination: no mappable fields: every field of gocsv.hidden is unexported or tagged csv:"-"`
+	if err != nil && err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+
+	// Rejected destinations do not consume a record
+	var n int
+	if err := reader.ReadNext(&n); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct, got %v", err)
+	}
+	var person struct {
+		Name string `csv:"name"`
+	}
+	if err := reader.ReadNext(&person); err != nil || person.Name != "Alice" {
+		t.Errorf("expected the first row after rejected destinations, got %+v (err %v)", person, err)
+	}
+
+	// A field whose column is absent is still mappable
+	var other struct {
+		City string `csv:"city"`
+	}
+	if err := reader.Prepare(other); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReadPointerElements(t *testing.T) {
+	content := "string_field,int_field\na,1\nb,2\nc,3"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var first *TestStruct
+	if err := reader.ReadNext(&first); err != nil {
+		t.Fatalf("ReadNext into **TestStruct failed: %v", err)
+	}
+	if first == nil || first.StringField != "a" || first.IntField != 1 {
+		t.Errorf("expected a newly allocated struct for row 1, got %+v", first)
+	}
+	kept := first
+	if err := reader.ReadNext(&first); err != nil {
+		t.Fatalf("ReadNext into **TestStruct failed: %v", err)
+	}
+	if first != kept || first.StringField != "b" {
+		t.Errorf("expected the existing struct to be reused, got %+v", first)
+	}
+
+	var rest []*TestStruct
+	if err := reader.ReadAll(&rest); err != nil {
+		t.Fatalf("ReadAll into []*TestStruct failed: %v", err)
+	}
+	if len(rest) != 1 || rest[0] == nil || rest[0].StringField != "c" || rest[0].IntField != 3 {
+		t.Errorf("unexpected pointer elements: %+v", rest)
+	}
+
+	var ints []*int
+	if err := reader.ReadAll(&ints); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct for []*int, got %v", err)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	_, err := NewCSVReaderFromReader(strings.NewReader(""))
+	if !errors.Is(err, ErrEmptyFile) {
+		t.Errorf("expected ErrEmptyFile for empty input, got %v", err)
+	} else if err.Error() != "field headers: empty file" {
+		t.Errorf("unexpected message for empty input: %q", err.Error())
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("string_field,int_field\na,1\nb,2\nc,3"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got TestStruct
+	if err := reader.ReadNext(got); !errors.Is(err, ErrNotPointer) {
+		t.Errorf("expected ErrNotPointer for a struct value, got %v", err)
+	}
+	var n int
+	if err := reader.ReadNext(&n); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct for *int, got %v", err)
+	}
+	var ints []int
+	if err := reader.ReadAll(&ints); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("expected ErrNotStruct for []int, got %v", err)
+	}
+
+	type needsEmail struct {
+		Email string `csv:"email,,required"`
+	}
+	if err := reader.ValidateHeaders(needsEmail{}); !errors.Is(err, ErrColumnMissing) {
+		t.Errorf("expected ErrColumnMissing from ValidateHeaders, got %v", err)
+	}
+	if err := reader.ExpectHeaders([]string{"string_field", "int_field", "email"}); !errors.Is(err, ErrColumnMissing) {
+		t.Errorf("expected ErrColumnMissing from ExpectHeaders, got %v", err)
+	}
+	reader.StrictColumns(true)
+	if err := reader.ReadNext(&got); !errors.Is(err, ErrColumnMissing) {
+		t.Errorf("expected ErrColumnMissing in strict mode, got %v", err)
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	content := "string_field,int_field\n" +
+		"a,1\n" +
+		"\"multi\nline\" x,2\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = reader.ReadNext(&got)
+	csvErr, ok := err.(*CSVError)
+	if !ok {
+		t.Fatalf("expected *CSVError, got %T: %v", err, err)
+	}
+	if csvErr.Line != 4 || csvErr.Column != 5 || !errors.Is(err, csv.ErrQuote) {
+		t.Errorf("expected csv.ErrQuote at line 4, column 5, got %v", err)
+	}
+	if !strings.HasPrefix(csvErr.Error(), "line 4, column 5: ") {
+		t.Errorf("expected the position in the message, got %q", csvErr.Error())
+	}
+
+	_, err = NewCSVReaderFromReader(strings.NewReader("name,\"bad\"x\n"))
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Field != "headers" || csvErr.Line != 1 || csvErr.Column == 0 {
+		t.Errorf("expected a positioned header CSVError, got %v", err)
+	}
+}
+
+func TestErrorLineNumbers(t *testing.T) {
+	content := "string_field,int_field\n" +
+		"\"multi\nline\",1\n" +
+		"value2,2\n" +
+		"value3,abc\n"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got TestStruct
+	for i := 0; i < 2; i++ {
+		if err := reader.ReadNext(&got); err != nil {
+			t.Fatalf("row %d: unexpected error: %v", i, err)
+		}
+	}
+
+	err = reader.ReadNext(&got)
+	csvErr, ok := err.(*CSVError)
+	if !ok {
+		t.Fatalf("expected *CSVError, got %T: %v", err, err)
+	}
+	if csvErr.Line != 5 {
+		t.Errorf("expected line 5, got %d", csvErr.Line)
+	}
+	if csvErr.Row != 3 {
+		t.Errorf("expected row 3, got %d", csvErr.Row)
+	}
+}
+
+func TestMultilineQuotedFields(t *testing.T) {
+	type item struct {
+		ID          int    `csv:"id"`
+		Description string `csv:"description,notrim"`
+		Qty         int    `csv:"qty"`
+	}
+
+	body := "id,description,qty\n" +
+		"1,\"first line\nsecond line\",2\n" +
+		"2,\"crlf\r\nline\",3\n" +
+		"3,\"ends with newline\n\",x\n" +
+		"4,plain,5\n"
+	want := []item{
+		{ID: 1, Description: "first line\nsecond line", Qty: 2},
+		{ID: 2, Description: "crlf\nline", Qty: 3},
+		{ID: 4, Description: "plain", Qty: 5},
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		opts      []Option
+		errorLine int
+	}{
+		{"no preamble", body, nil, 6},
+		{"skipped preamble", "exported 2024-01-01\n" + body, []Option{WithSkipRows(1)}, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewCSVReaderFromReader(strings.NewReader(tt.content), tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to create reader: %v", err)
+			}
+
+			var got []item
+			rowErrors, err := reader.ReadAllLenient(&got)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+			if len(rowErrors) != 1 || rowErrors[0].Line != tt.errorLine {
+				t.Fatalf("expected one row error on line %d, got %v", tt.errorLine, rowErrors)
+			}
+			var csvErr *CSVError
+			if !errors.As(rowErrors[0].Err, &csvErr) || csvErr.Row != 3 || csvErr.Line != tt.errorLine {
+				t.Errorf("expected CSVError for row 3 on line %d, got %v", tt.errorLine, rowErrors[0].Err)
+			}
+		})
+	}
+}
+
+func TestReadWithContext(t *testing.T) {
+	content := "string_field,int_field\nvalue1,1\nvalue2,2"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var got TestStruct
+	if err := reader.ReadNextCtx(ctx, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancel()
+	if err := reader.ReadNextCtx(ctx, &got); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	var all []TestStruct
+	if err := reader.ReadAllCtx(ctx, &all); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("expected no rows after cancel, got %d", len(all))
+	}
+
+	if err := reader.ReadAllCtx(context.Background(), &all); err != nil || len(all) != 1 {
+		t.Errorf("expected remaining row to be read, got %d rows (err %v)", len(all), err)
+	}
+}
+
+func TestReadNextConcurrent(t *testing.T) {
+	const rows = 1000
+	reader, err := NewCSVReaderFromReader(strings.NewReader(generateCSVContent(rows)))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var dest BenchStruct
+			for reader.ReadNext(&dest) == nil {
+				mu.Lock()
+				total++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if total != rows {
+		t.Errorf("expected %d rows, got %d", rows, total)
+	}
+}
+
+func TestNewCSVReaderWithOptions(t *testing.T) {
+	content := "Daily export\n# generated by report-service\nstring_field;date_field\nvalue1;31/01/2024"
+	tmpFile := createTempFile(t, content)
+
+	reader, err := NewCSVReaderWithOptions(tmpFile,
+		WithSkipRows(1),
+		WithComment('#'),
+		WithDelimiter(';'),
+		WithTimeLayout("02/01/2006"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.StringField != "value1" || !got.DateField.Equal(mustParseTime("2024-01-31")) {
+		t.Errorf("got %+v, want StringField=value1 DateField=2024-01-31", got)
+	}
+
+	if _, err := NewCSVReaderWithOptions(tmpFile, WithTimeLayout("15:04")); err == nil {
+		t.Error("expected error for invalid time layout, got nil")
+	}
+}
+
+func TestReset(t *testing.T) {
+	content := "title\nstring_field,int_field\nvalue1,1\nvalue2,2"
+	tmpFile := createTempFile(t, content)
+
+	reader, err := NewCSVReaderWithOptions(tmpFile, WithSkipRows(1))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	for pass := 0; pass < 2; pass++ {
+		var got []TestStruct
+		if err := reader.ReadAll(&got); err != nil {
+			t.Fatalf("pass %d: unexpected error: %v", pass, err)
+		}
+		if len(got) != 2 || got[1].IntField != 2 {
+			t.Errorf("pass %d: unexpected rows: %+v", pass, got)
+		}
+		if err := reader.Reset(); err != nil {
+			t.Fatalf("pass %d: failed to reset: %v", pass, err)
+		}
+	}
+
+	if err := os.WriteFile(tmpFile, []byte("title\nother,columns\n"), 0644); err != nil {
+		t.Fatalf("failed to rewrite file: %v", err)
+	}
+	if err := reader.Reset(); err == nil {
+		t.Error("expected error for changed header, got nil")
+	}
+	// The failed Reset leaves the reader where it was
+	if got := reader.Headers(); !reflect.DeepEqual(got, []string{"string_field", "int_field"}) {
+		t.Errorf("expected the original header after a failed reset, got %v", got)
+	}
+	var row TestStruct
+	if err := reader.ReadNext(&row); err != nil || row.StringField != "value1" {
+		t.Errorf("expected the first row after a failed reset, got %+v (err %v)", row, err)
+	}
+
+	// Reset returns to where the CSV started, not to the start of src
+	src := strings.NewReader("preamble\n" + content)
+	src.Seek(int64(len("preamble\n")), io.SeekStart)
+	offset, err := NewCSVReaderFromReader(src, WithSkipRows(1))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := offset.Reset(); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	if err := offset.ReadNext(&row); err != nil || row.StringField != "value1" {
+		t.Errorf("expected the first row after reset, got %+v (err %v)", row, err)
+	}
+
+	piped, err := NewCSVReaderFromReader(io.MultiReader(strings.NewReader(content)))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := piped.Reset(); err != ErrNotSeekable {
+		t.Errorf("expected ErrNotSeekable, got %v", err)
+	}
+}
+
+func TestCount(t *testing.T) {
+	content := "string_field,int_field\nvalue1,1\nvalue2\n\"multi\nline\",3"
+	tmpFile := createTempFile(t, content)
+
+	reader, err := NewCSVReader(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	count, err := reader.Count()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 rows, got %d", count)
+	}
+
+	if err := reader.Reset(); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil || got.StringField != "value1" {
+		t.Errorf("expected first row after reset, got %+v (err %v)", got, err)
+	}
+}
+
+func TestReadNextMalformedRecord(t *testing.T) {
+	content := "string_field,int_field\nvalue1,1\nvalue2,2,extra"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = reader.ReadNext(&got)
+	csvErr, ok := err.(*CSVError)
+	if !ok {
+		t.Fatalf("expected *CSVError, got %T: %v", err, err)
+	}
+	if csvErr.Line != 3 || !errors.Is(csvErr.Wrapped, csv.ErrFieldCount) {
+		t.Errorf("expected field count error on line 3, got %v", csvErr)
+	}
+	if IsEOF(err) {
+		t.Error("malformed record should not be reported as EOF")
+	}
+
+	err = reader.ReadNext(&got)
+	if err != io.EOF || !IsEOF(err) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestSetTrimSpace(t *testing.T) {
+	type code struct {
+		Code  string `csv:"code"`
+		Label string `csv:"label"`
+		Pad   string `csv:"pad,notrim"`
+		Count int    `csv:"count"`
+	}
+
+	content := "code,label,pad,count\n  AB ,  x  ,  y  , 3 "
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var got code
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := code{Code: "AB", Label: "x", Pad: "  y  ", Count: 3}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetTrimSpace(false)
+	got = code{}
+	err = reader.ReadNext(&got)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Type != "int" {
+		t.Errorf("expected int CSVError for padded count, got %v", err)
+	}
+	if got.Code != "  AB " || got.Label != "  x  " {
+		t.Errorf("expected untrimmed values, got %+v", got)
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	tmpFile := createTempFile(t, generateCSVContent(50))
+
+	reader, err := NewCSVReaderWithOptions(tmpFile, WithBufferSize(16))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got []TestStruct
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 50 || got[49].StringField != "value49" {
+		t.Errorf("unexpected rows: %d", len(got))
+	}
+
+	if err := reader.SetBufferSize(0); err == nil {
+		t.Error("expected error for zero buffer size, got nil")
+	}
+	if err := reader.SetBufferSize(1 << 16); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := reader.Reset(); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	got = nil
+	if err := reader.ReadAll(&got); err != nil || len(got) != 50 {
+		t.Errorf("got %d rows after reset, %v; want 50", len(got), err)
+	}
+
+	if _, err := NewCSVReaderWithOptions(tmpFile, WithBufferSize(-1)); err == nil {
+		t.Error("expected error for negative buffer size, got nil")
+	}
+}
+
+func TestReadNextReusesDestination(t *testing.T) {
+	type row struct {
+		Name  *string `csv:"name"`
+		Count *int    `csv:"count"`
+		Note  string  `csv:"note"`
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,count,note\nalice,1,hi\nbob,,\ncarol,3,"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got row
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	name := got.Name
+
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != name || *got.Name != "bob" {
+		t.Errorf("expected the name pointer to be reused, got %p (%v), want %p", got.Name, *got.Name, name)
+	}
+	if got.Count != nil || got.Note != "" {
+		t.Errorf("expected empty cells to reset the fields, got %+v", got)
+	}
+
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *got.Name != "carol" || got.Count == nil || *got.Count != 3 {
+		t.Errorf("unexpected value: %+v", got)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var dest BenchStruct
+		destValue := reflect.ValueOf(&dest).Elem()
+		record := []string{"s", "1", "1.5", "true", "2024-01-01", "optional"}
+		for i := 0; i < 10; i++ {
+			reader.populateStruct(destValue, record)
+		}
+	})
+	// The first row allocates the *string target, later rows reuse it
+	if allocs > 1 {
+		t.Errorf("got %v allocations for 10 rows, want at most 1", allocs)
+	}
+}
+
+func TestSetLimit(t *testing.T) {
+	reader, err := NewCSVReader(createTempFile(t, generateCSVContent(10)))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	if err := reader.SetLimit(-1); err == nil {
+		t.Error("expected error for negative limit, got nil")
+	}
+	if err := reader.SetLimit(3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []TestStruct
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Errorf("got %d rows, want 3", len(got))
+	}
+	var row TestStruct
+	if err := reader.ReadNext(&row); err != io.EOF {
+		t.Errorf("expected io.EOF after limit, got %v", err)
+	}
+
+	if err := reader.Reset(); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	if err := reader.SetLimit(0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got = nil
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 10 {
+		t.Errorf("got %d rows after removing the limit, want 10", len(got))
+	}
+}
+
+func TestSetVariableColumns(t *testing.T) {
+	content := "string_field,int_field,float_field\nvalue1,1\nvalue2,2,2.5,extra\nvalue3,3,3.5"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetVariableColumns(true)
+
+	var got []TestStruct
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 || got[0].FloatField != 0 || got[1].FloatField != 2.5 || got[2].IntField != 3 {
+		t.Errorf("unexpected rows: %+v", got)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetVariableColumns(true)
+	reader.SetVariableColumns(false)
+	var row TestStruct
+	if err := reader.ReadNext(&row); err == nil {
+		t.Error("expected field count error after disabling, got nil")
+	}
+}
+
+func TestSetTimeLayout(t *testing.T) {
+	tests := []struct {
+		name        string
+		layout      string
+		expectError bool
+	}{
+		{
+			name:        "valid layout - standard",
+			layout:      "2006-01-02",
+			expectError: false,
+		},
+		{
+			name:        "valid layout - with time",
+			layout:      "2006-01-02 15:04:05",
+			expectError: false,
+		},
+		{
+			name:        "empty layout",
+			layout:      "",
+			expectError: true,
+		},
+		{
+			name:        "invalid layout - random string",
+			layout:      "invalid-format",
+			expectError: true,
+		},
+		{
+			name:        "invalid layout - partial date",
+			layout:      "2006",
+			expectError: true,
+		},
+		{
+			name:        "invalid layout - wrong reference date",
+			layout:      "2007-01-02",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &CSVReader{
+				timeLayout: "2006-01-02", // default layout
+			}
+			err := reader.SetTimeLayout(tt.layout)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error for layout '%s', got nil", tt.layout)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("unexpected error for layout '%s': %v", tt.layout, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSliceFields(t *testing.T) {
+	type post struct {
+		Tags   []string  `csv:"tags"`
+		Scores []int     `csv:"scores"`
+		Flags  *[]bool   `csv:"flags"`
+		Ratios []float64 `csv:"ratios"`
+	}
+
+	content := "tags,scores,flags,ratios\ngo;csv; parser,1;2;3,,0.5\n,,yes;no,"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got []post
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got[0].Tags, []string{"go", "csv", "parser"}) ||
+		!reflect.DeepEqual(got[0].Scores, []int{1, 2, 3}) || got[0].Flags != nil {
+		t.Errorf("unexpected first row: %+v", got[0])
+	}
+	if got[1].Tags == nil || len(got[1].Tags) != 0 || got[1].Ratios == nil {
+		t.Errorf("expected empty non-nil slices, got %+v", got[1])
+	}
+	if got[1].Flags == nil || !reflect.DeepEqual(*got[1].Flags, []bool{true, false}) {
+		t.Errorf("unexpected flags: %v", got[1].Flags)
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("tags,scores\na|b,1|x"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetSliceSeparator("|"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var bad post
+	if err := reader.ReadNext(&bad); err == nil {
+		t.Error("expected error for invalid element, got nil")
+	}
+	if !reflect.DeepEqual(bad.Tags, []string{"a", "b"}) {
+		t.Errorf("unexpected tags: %v", bad.Tags)
+	}
+}
+
+func TestArrayFields(t *testing.T) {
+	type pixel struct {
+		RGB  [3]int     `csv:"rgb"`
+		Tags [2]string  `csv:"tags"`
+		Pos  *[2]uint16 `csv:"pos"`
+	}
+
+	content := "rgb,tags,pos\n10;20;30, a ;b,1;2\n,x;y,\n1;2,x;y,\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var p pixel
+	if err := reader.ReadNext(&p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.RGB != [3]int{10, 20, 30} || p.Tags != [2]string{"a", "b"} || p.Pos == nil || *p.Pos != [2]uint16{1, 2} {
+		t.Errorf("unexpected first row: %+v", p)
+	}
+
+	if err := reader.ReadNext(&p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.RGB != [3]int{} || p.Pos != nil {
+		t.Errorf("expected empty cells to reset the arrays, got %+v", p)
+	}
+
+	err = reader.ReadNext(&p)
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || csvErr.Type != "[3]int" || csvErr.Value != "1;2" {
+		t.Errorf("expected an element count error, got %v", err)
+	}
+}
+
+func TestAbsolutePositionTags(t *testing.T) {
+	type entry struct {
+		Name  string `csv:"name"`
+		First string `csv:"@1"`
+		Last  int    `csv:"@3"`
+		Inner struct {
+			Note string `csv:"@2"`
+		} `csv:"inner"`
+	}
+
+	content := "name,,,\nAlice,a,b,3\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got entry
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Name != "Alice" || got.First != "a" || got.Last != 3 || got.Inner.Note != "b" {
+		t.Errorf("unexpected value: %+v", got)
+	}
+
+	if reader.HasColumn("@4") {
+		t.Error("expected a position past the header to be missing")
+	}
+	if !reader.HasColumn("@0") || reader.HasColumn("x@0") {
+		t.Error("unexpected position matching")
+	}
+
+	// A position past the header is an error, not an absent column
+	var beyond struct {
+		Name  string `csv:"name"`
+		Extra string `csv:"@4"`
+	}
+	err = reader.Prepare(beyond)
+	if !errors.Is(err, ErrColumnMissing) || err.Error() != "field @4: column missing: position 4 is beyond the 4 header columns" {
+		t.Errorf("expected an out of range position error, got %v", err)
+	}
+	reader, err = NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.ReadNext(&beyond); !errors.Is(err, ErrColumnMissing) {
+		t.Errorf("expected ReadNext to report the position, got %v", err)
+	}
+}
+
+func TestRegisterConverter(t *testing.T) {
+	type member struct {
+		Name   string `csv:"name"`
+		Active bool   `csv:"active"`
+		Level  *int   `csv:"level"`
+	}
+
+	content := "name,active,level\nalice,Y,gold\nbob,N,"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.RegisterConverter("active", func(value string) (interface{}, error) {
+		return value == "Y", nil
+	})
+	reader.RegisterConverter("level", func(value string) (interface{}, error) {
+		levels := map[string]int{"silver": 1, "gold": 2}
+		level, ok := levels[value]
+		if !ok {
+			return nil, fmt.Errorf("unknown level %q", value)
+		}
+		return level, nil
+	})
+	reader.RegisterConverter("name", func(value string) (interface{}, error) {
+		return strings.ToUpper(value), nil
+	})
+
+	var got []member
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].Name != "ALICE" || !got[0].Active || got[0].Level == nil || *got[0].Level != 2 {
+		t.Errorf("unexpected first row: %+v", got[0])
+	}
+	if got[1].Active || got[1].Level != nil {
+		t.Errorf("unexpected second row: %+v", got[1])
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("name,active\nalice,Y"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.RegisterConverter("active", func(value string) (interface{}, error) {
+		return "yes", nil
+	})
+	var bad member
+	if _, ok := reader.ReadNext(&bad).(*CSVError); !ok {
+		t.Error("expected CSVError for type mismatch")
+	}
+}
+
+// money is a custom type decoded through CSVUnmarshaler
+type money struct {
+	cents int64
+}
+
+func (m *money) UnmarshalCSV(value string) error {
+	f, err := strconv.ParseFloat(strings.TrimPrefix(value, "$"), 64)
+	if err != nil {
+		return err
+	}
+	m.cents = int64(math.Round(f * 100))
+	return nil
+}
+
+func TestCSVUnmarshaler(t *testing.T) {
+	type order struct {
+		Total    money  `csv:"total"`
+		Discount *money `csv:"discount"`
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("total,discount\n$12.34,$1.50\n$1.00,"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got []order
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].Total.cents != 1234 || got[0].Discount == nil || got[0].Discount.cents != 150 {
+		t.Errorf("unexpected first row: %+v", got[0])
+	}
+	if got[1].Total.cents != 100 || got[1].Discount != nil {
+		t.Errorf("unexpected second row: %+v", got[1])
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader("total\nabc"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var bad order
+	if err := reader.ReadNext(&bad); err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func TestTextUnmarshaler(t *testing.T) {
+	type host struct {
+		Addr net.IP  `csv:"addr"`
+		Mask *net.IP `csv:"mask"`
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("addr,mask\n10.0.0.1,255.255.255.0\nnot-an-ip,"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got host
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Addr.Equal(net.ParseIP("10.0.0.1")) || got.Mask == nil || !got.Mask.Equal(net.ParseIP("255.255.255.0")) {
+		t.Errorf("unexpected value: %+v", got)
+	}
+
+	if err := reader.ReadNext(&got); err == nil {
+		t.Error("expected error for invalid IP, got nil")
+	}
+}
+
+func TestSetTimeLayouts(t *testing.T) {
+	content := "date_field\n2024-01-15\n01/16/2024\nJan 17 2024"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetTimeLayouts("01/02/2006", "Jan 02 2006"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []TestStruct
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, day := range []string{"2024-01-15", "2024-01-16", "2024-01-17"} {
+		if !got[i].DateField.Equal(mustParseTime(day)) {
+			t.Errorf("row %d: got %v, want %s", i, got[i].DateField, day)
+		}
+	}
+
+	if err := reader.SetTimeLayouts("2006-01-02", "invalid"); err == nil {
+		t.Error("expected error for invalid layout, got nil")
+	}
+}
+
+func TestUnixTimeFormats(t *testing.T) {
+	type event struct {
+		At      time.Time  `csv:"at,format=unix"`
+		AtMilli *time.Time `csv:"at_ms,format=unixmilli"`
+	}
+
+	content := "at,at_ms\n1700000000,1700000000123\n-1,0\n2023-11-14,1\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got event
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.At.Equal(time.Unix(1700000000, 0)) || got.At.Location() != time.UTC {
+		t.Errorf("unexpected unix time: %v", got.At)
+	}
+	if got.AtMilli == nil || !got.AtMilli.Equal(time.UnixMilli(1700000000123)) {
+		t.Errorf("unexpected unixmilli time: %v", got.AtMilli)
+	}
+
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.At.Equal(time.Unix(-1, 0)) || !got.AtMilli.Equal(time.Unix(0, 0)) {
+		t.Errorf("unexpected times: %v, %v", got.At, got.AtMilli)
+	}
+
+	err = reader.ReadNext(&got)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Type != "time.Time" || csvErr.Value != "2023-11-14" {
+		t.Errorf("expected time.Time CSVError for a date in a unix column, got %v", err)
+	}
+}
+
+func TestSetAcceptScientificInts(t *testing.T) {
+	type record struct {
+		ID    int64 `csv:"id"`
+		Count uint8 `csv:"count"`
+	}
+	read := func(content string, accept bool) (record, error) {
+		reader, err := NewCSVReaderFromReader(strings.NewReader("id,count\n" + content))
+		if err != nil {
+			t.Fatalf("failed to create reader: %v", err)
+		}
+		reader.SetAcceptScientificInts(accept)
+		var got record
+		return got, reader.ReadNext(&got)
+	}
+
+	if _, err := read("1.23E+08,1", false); err == nil {
+		t.Error("expected scientific notation to be rejected by default")
+	}
+
+	got, err := read("1.23E+08,2.5e1", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ID != 123000000 || got.Count != 25 {
+		t.Errorf("unexpected value: %+v", got)
+	}
+	if got, err := read("-4e2,7", true); err != nil || got.ID != -400 || got.Count != 7 {
+		t.Errorf("unexpected result for a negative value: %+v, %v", got, err)
+	}
+
+	for _, content := range []string{
+		"1.5E+00,1", // fractional
+		"1E+20,1",   // beyond exact float64 integers
+		"abc,1",     // not a number
+		"1,3e2",     // overflows uint8
+		"1,-1e1",    // negative uint
+	} {
+		var csvErr *CSVError
+		if _, err := read(content, true); !errors.As(err, &csvErr) {
+			t.Errorf("%s: expected a CSVError, got %v", content, err)
+		}
+	}
+
+	// Narrow signed fields are range checked like unsigned ones
+	reader, err := NewCSVReaderFromReader(strings.NewReader("small\n1e3\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetAcceptScientificInts(true)
+	var small struct {
+		Small int8 `csv:"small"`
+	}
+	if err := reader.ReadNext(&small); err == nil || !strings.Contains(err.Error(), "1e3 is out of range") {
+		t.Errorf("expected 1e3 to overflow int8, got %+v, %v", small, err)
+	}
+}
+
+func TestReadAllWithLayout(t *testing.T) {
+	type event struct {
+		Name string    `csv:"name"`
+		At   time.Time `csv:"at"`
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,at\nlaunch,25/12/2023\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetStrictTime(true)
+
+	if err := reader.ReadAllWithLayout(&[]event{}, "bad"); err == nil {
+		t.Error("expected an invalid layout to be rejected")
+	}
+
+	var got []event
+	if err := reader.ReadAllWithLayout(&got, "02/01/2006"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC)
+	if len(got) != 1 || !got[0].At.Equal(want) {
+		t.Errorf("unexpected rows: %+v", got)
+	}
+
+	// The reader's own layout is still in effect afterwards
+	if err := reader.Reset(); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	if err := reader.ReadAll(&got); err == nil {
+		t.Error("expected the default layout to reject 25/12/2023")
+	}
+}
+
+func TestSetStrictTime(t *testing.T) {
+	content := "date_field\n2024-01-15\n2024-01-16T10:00:00Z\n01/18/2024"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetTimeLayouts("01/02/2006"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reader.SetStrictTime(true)
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error for the field's layout: %v", err)
+	}
+	for _, value := range []string{"2024-01-16T10:00:00Z", "01/18/2024"} {
+		err := reader.ReadNext(&got)
+		if csvErr, ok := err.(*CSVError); !ok || csvErr.Type != "time.Time" || csvErr.Value != value {
+			t.Errorf("expected time.Time CSVError for %s in strict mode, got %v", value, err)
+		}
+	}
+
+	reader.SetStrictTime(false)
+	if err := reader.Reset(); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+	var all []TestStruct
+	if err := reader.ReadAll(&all); err != nil {
+		t.Errorf("expected the fallback to accept every row once strict mode is off, got %v", err)
+	}
+}
+
+func TestDetectedTimeLayout(t *testing.T) {
+	content := "date_field\n2024-01-15\n2024-01-16T10:00:00Z\n2024-01-17T11:00:00Z\n01/18/2024"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetTimeLayouts("01/02/2006"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if layout := reader.DetectedTimeLayout(); layout != "" {
+		t.Errorf("expected no detected layout for the default format, got %q", layout)
+	}
+
+	for i, want := range []string{RFC3339, RFC3339, "01/02/2006"} {
+		if err := reader.ReadNext(&got); err != nil {
+			t.Fatalf("row %d: unexpected error: %v", i, err)
+		}
+		if layout := reader.DetectedTimeLayout(); layout != want {
+			t.Errorf("row %d: detected layout %q, want %q", i, layout, want)
+		}
+	}
+	if !got.DateField.Equal(mustParseTime("2024-01-18")) {
+		t.Errorf("got %v, want 2024-01-18", got.DateField)
+	}
+}
+
+func TestTimeLayoutsOrderWithDetection(t *testing.T) {
+	// 13/01/2024 only parses day first, but 03/04/2024 must still be read
+	// month first because that layout is listed first
+	content := "date_field\n13/01/2024\n03/04/2024\n"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetTimeLayouts("01/02/2006", "02/01/2006"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rows []TestStruct
+	if err := reader.ReadAll(&rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, want := range []string{"2024-01-13", "2024-03-04"} {
+		if !rows[i].DateField.Equal(mustParseTime(want)) {
+			t.Errorf("row %d: got %v, want %s", i, rows[i].DateField, want)
+		}
+	}
+}
+
+func TestSetLocation(t *testing.T) {
+	type event struct {
+		At time.Time `csv:"at,2006-01-02 15:04"`
+	}
+
+	loc := time.FixedZone("UTC+7", 7*60*60)
+	reader, err := NewCSVReaderFromReader(strings.NewReader("at\n2024-01-01 10:00"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.SetLocation(loc)
+
+	var got event
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := time.Date(2024, time.January, 1, 10, 0, 0, 0, loc)
+	if !got.At.Equal(expected) {
+		t.Errorf("got %v, want %v", got.At, expected)
+	}
+}
+
+func TestDurationFields(t *testing.T) {
+	type job struct {
+		Timeout time.Duration  `csv:"timeout"`
+		Retry   *time.Duration `csv:"retry"`
+	}
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader("timeout,retry\n1h30m,30s\n10,"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got job
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Timeout != 90*time.Minute || got.Retry == nil || *got.Retry != 30*time.Second {
+		t.Errorf("unexpected value: %+v", got)
+	}
+
+	err = reader.ReadNext(&got)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Type != "time.Duration" {
+		t.Errorf("expected time.Duration CSVError, got %v", err)
+	}
+}
+
+func TestComplexAndBigFields(t *testing.T) {
+	type measurement struct {
+		Impedance complex128 `csv:"impedance"`
+		Phase     complex64  `csv:"phase"`
+		Count     *big.Int   `csv:"count"`
+		Total     big.Int    `csv:"total"`
+		Ratio     *big.Float `csv:"ratio"`
+	}
+
+	content := "impedance,phase,count,total,ratio\n" +
+		"\"(3+4i)\",1.5i,123456789012345678901234567890,\"1,000,000\",0.125\n" +
+		"x,,,,\n" +
+		",,12a,,\n" +
+		",,,,1.2.3"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetThousandsSeparator(','); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got measurement
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	count, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	if got.Impedance != complex(3, 4) || got.Phase != complex64(1.5i) ||
+		got.Count.Cmp(count) != 0 || got.Total.Int64() != 1000000 ||
+		got.Ratio.Cmp(big.NewFloat(0.125)) != 0 {
+		t.Errorf("unexpected value: %+v", got)
+	}
+
+	for _, wantType := range []string{"complex", "*big.Int", "*big.Float"} {
+		var row measurement
+		err := reader.ReadNext(&row)
+		if csvErr, ok := err.(*CSVError); !ok || csvErr.Type != wantType {
+			t.Errorf("expected %s CSVError, got %v", wantType, err)
+		}
+	}
+}
+
+func TestByteSliceFields(t *testing.T) {
+	type blob struct {
+		Raw     []byte `csv:"raw"`
+		Payload []byte `csv:"payload,encoding=base64"`
+	}
+
+	content := "raw,payload\nhello,aGVsbG8gd29ybGQ=\n,not base64!"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got blob
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got.Raw) != "hello" || string(got.Payload) != "hello world" {
+		t.Errorf("unexpected value: raw %q, payload %q", got.Raw, got.Payload)
+	}
+
+	err = reader.ReadNext(&got)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Type != "[]byte" || csvErr.Field != "payload" {
+		t.Errorf("expected []byte CSVError for payload, got %v", err)
+	}
+
+	type unsupported struct {
+		Data []byte `csv:"raw,encoding=rot13"`
+	}
+	reader, err = NewCSVReaderFromReader(strings.NewReader("raw\nabc"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var u unsupported
+	if err := reader.ReadNext(&u); err == nil {
+		t.Error("expected error for unsupported encoding, got nil")
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	type settings struct {
+		Theme string `json:"theme"`
+		Size  int    `json:"size"`
+	}
+	type row struct {
+		ID       int            `csv:"id"`
+		Settings settings       `csv:"settings,json"`
+		Extra    map[string]int `csv:"extra,json"`
+		Tags     *[]string      `csv:"tags,json"`
+	}
 
-			reader, err := NewCSVReader(tmpFile)
-			if tt.expectError {
-				if err == nil {
-					t.Error("expected error, got nil")
-				}
-				return
-			}
+	content := "id,settings,extra,tags\n" +
+		`1,"{""theme"":""dark"",""size"":12}","{""k"":1}","[""a"",""b""]"` + "\n" +
+		"2,,,\n" +
+		"3,{not json},,\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
 
-			if err != nil {
-				t.Errorf("unexpected error: %v", err)
-				return
-			}
+	var got row
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := row{ID: 1, Settings: settings{Theme: "dark", Size: 12}, Extra: map[string]int{"k": 1}, Tags: &[]string{"a", "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
 
-			if reader == nil {
-				t.Error("expected reader to not be nil")
-				return
-			}
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error for empty cells: %v", err)
+	}
+	if !reflect.DeepEqual(got, row{ID: 2}) {
+		t.Errorf("expected empty cells to reset the fields, got %+v", got)
+	}
 
-			defer reader.Close()
-		})
+	err = reader.ReadNext(&got)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Type != "json" || csvErr.Field != "settings" {
+		t.Errorf("expected json CSVError for settings, got %v", err)
 	}
 }
 
-func TestReadNext(t *testing.T) {
-	content := `string_field,int_field,float_field,bool_field,date_field,optional_field
-value1,123,45.67,true,2024-01-01,optional
-value2,-456,78.90,false,2024-02-01,
-value3,789,12.34,yes,2024-03-01,test`
+func TestIntegerBases(t *testing.T) {
+	type register struct {
+		Hex   int    `csv:"hex,base=16"`
+		Octal uint16 `csv:"octal,base=8"`
+		Auto  int64  `csv:"auto,base=0"`
+		Plain int    `csv:"plain"`
+	}
 
-	tmpFile := createTempFile(t, content)
-	defer os.Remove(tmpFile)
+	content := "hex,octal,auto,plain\n" +
+		"0x1F,0o755,0x10,42\n" +
+		"-ff,755,0o17,0042\n" +
+		"1F,,0b101,\n" +
+		",,,0x1F"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
 
-	reader, err := NewCSVReader(tmpFile)
+	expected := []register{
+		{Hex: 31, Octal: 0755, Auto: 16, Plain: 42},
+		{Hex: -255, Octal: 0755, Auto: 15, Plain: 42},
+		{Hex: 31, Auto: 5},
+	}
+	for i, want := range expected {
+		var got register
+		if err := reader.ReadNext(&got); err != nil {
+			t.Fatalf("row %d: unexpected error: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("row %d: got %+v, want %+v", i, got, want)
+		}
+	}
+
+	var got register
+	if err := reader.ReadNext(&got); err == nil {
+		t.Error("expected error for 0x prefix without base option, got nil")
+	}
+}
+
+func TestSetBoolValues(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("bool_field\nON\noff\nyes"))
 	if err != nil {
 		t.Fatalf("failed to create reader: %v", err)
 	}
-	defer reader.Close()
+	if err := reader.SetBoolValues([]string{"on", "Y"}, []string{"off", "N"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 
-	expected := []TestStruct{
-		{
-			StringField: "value1",
-			IntField:    123,
-			FloatField:  45.67,
-			BoolField:   true,
-			DateField:   mustParseTime("2024-01-01"),
-			OptionalPtr: strPtr("optional"),
-		},
-		{
-			StringField: "value2",
-			IntField:    -456,
-			FloatField:  78.90,
-			BoolField:   false,
-			DateField:   mustParseTime("2024-02-01"),
-			OptionalPtr: nil,
-		},
-		{
-			StringField: "value3",
-			IntField:    789,
-			FloatField:  12.34,
-			BoolField:   true,
-			DateField:   mustParseTime("2024-03-01"),
-			OptionalPtr: strPtr("test"),
-		},
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil || !got.BoolField {
+		t.Errorf("expected true, got %v (err %v)", got.BoolField, err)
+	}
+	if err := reader.ReadNext(&got); err != nil || got.BoolField {
+		t.Errorf("expected false, got %v (err %v)", got.BoolField, err)
+	}
+	if err := reader.ReadNext(&got); err == nil {
+		t.Error("expected error for unregistered token, got nil")
 	}
 
-	for i, exp := range expected {
-		var got TestStruct
-		err := reader.ReadNext(&got)
-		if err != nil {
-			t.Errorf("row %d: unexpected error: %v", i, err)
-			continue
-		}
+	if err := reader.SetBoolValues([]string{"x"}, []string{"X"}); err == nil {
+		t.Error("expected error for overlapping tokens, got nil")
+	}
+}
 
-		if got.StringField != exp.StringField {
-			t.Errorf("row %d: StringField: got %v, want %v", i, got.StringField, exp.StringField)
-		}
-		if got.IntField != exp.IntField {
-			t.Errorf("row %d: IntField: got %v, want %v", i, got.IntField, exp.IntField)
-		}
-		if got.FloatField != exp.FloatField {
-			t.Errorf("row %d: FloatField: got %v, want %v", i, got.FloatField, exp.FloatField)
-		}
-		if got.BoolField != exp.BoolField {
-			t.Errorf("row %d: BoolField: got %v, want %v", i, got.BoolField, exp.BoolField)
-		}
-		if !got.DateField.Equal(exp.DateField) {
-			t.Errorf("row %d: DateField: got %v, want %v", i, got.DateField, exp.DateField)
-		}
-		if (got.OptionalPtr == nil) != (exp.OptionalPtr == nil) {
-			t.Errorf("row %d: OptionalPtr: got %v, want %v", i, got.OptionalPtr, exp.OptionalPtr)
-		} else if got.OptionalPtr != nil && *got.OptionalPtr != *exp.OptionalPtr {
-			t.Errorf("row %d: OptionalPtr value: got %v, want %v", i, *got.OptionalPtr, *exp.OptionalPtr)
+func TestDecimalSeparators(t *testing.T) {
+	content := "string_field;float_field\na;45,67\nb;1.234.567,89\nc;12"
+
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content), WithDelimiter(';'))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetDecimalSeparator(','); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := reader.SetThousandsSeparator('.'); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []TestStruct
+	if err := reader.ReadAll(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, expected := range []float64{45.67, 1234567.89, 12} {
+		if got[i].FloatField != expected {
+			t.Errorf("row %d: got %v, want %v", i, got[i].FloatField, expected)
 		}
 	}
+
+	if err := reader.SetThousandsSeparator(','); err == nil {
+		t.Error("expected error when separators are equal, got nil")
+	}
 }
 
-func TestSetTimeLayout(t *testing.T) {
-	tests := []struct {
-		name        string
-		layout      string
-		expectError bool
-	}{
-		{
-			name:        "valid layout - standard",
-			layout:      "2006-01-02",
-			expectError: false,
-		},
-		{
-			name:        "valid layout - with time",
-			layout:      "2006-01-02 15:04:05",
-			expectError: false,
-		},
-		{
-			name:        "empty layout",
-			layout:      "",
-			expectError: true,
-		},
-		{
-			name:        "invalid layout - random string",
-			layout:      "invalid-format",
-			expectError: true,
-		},
-		{
-			name:        "invalid layout - partial date",
-			layout:      "2006",
-			expectError: true,
-		},
-		{
-			name:        "invalid layout - wrong reference date",
-			layout:      "2007-01-02",
-			expectError: true,
-		},
+func TestIntegerGrouping(t *testing.T) {
+	type totals struct {
+		Signed   int64  `csv:"signed"`
+		Unsigned uint32 `csv:"unsigned"`
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			reader := &CSVReader{
-				timeLayout: "2006-01-02", // default layout
-			}
-			err := reader.SetTimeLayout(tt.layout)
+	content := "signed,unsigned\n\"-1,234,567\",\"4,000\""
 
-			if tt.expectError {
-				if err == nil {
-					t.Errorf("expected error for layout '%s', got nil", tt.layout)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("unexpected error for layout '%s': %v", tt.layout, err)
-				}
-			}
-		})
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got totals
+	if err := reader.ReadNext(&got); err == nil {
+		t.Fatal("expected error without a thousands separator, got nil")
+	}
+
+	reader, err = NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SetThousandsSeparator(','); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Signed != -1234567 || got.Unsigned != 4000 {
+		t.Errorf("unexpected value: %+v", got)
 	}
 }
 
@@ -321,6 +3228,37 @@ func BenchmarkReadNext(b *testing.B) {
 	}
 }
 
+// BenchmarkReadNextBufferSize benchmarks reading a large file with
+// different read buffer sizes
+func BenchmarkReadNextBufferSize(b *testing.B) {
+	const size = 10000
+	fileName, cleanup := setupBenchmarkFile(b, size)
+	defer cleanup()
+
+	for _, bufferSize := range []int{4 << 10, 64 << 10, 1 << 20} {
+		b.Run(fmt.Sprintf("buffer_%dKB", bufferSize>>10), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				reader, err := NewCSVReaderWithOptions(fileName, WithBufferSize(bufferSize))
+				if err != nil {
+					b.Fatalf("failed to create reader: %v", err)
+				}
+
+				b.StartTimer()
+				var dest BenchStruct
+				for j := 0; j < size; j++ {
+					if err := reader.ReadNext(&dest); err != nil {
+						b.Fatalf("failed to read record: %v", err)
+					}
+				}
+
+				b.StopTimer()
+				reader.Close()
+			}
+		})
+	}
+}
+
 // BenchmarkReadNextParallel benchmarks parallel reading from multiple goroutines
 func BenchmarkReadNextParallel(b *testing.B) {
 	sizes := []int{100, 1000, 10000}
@@ -398,3 +3336,71 @@ func BenchmarkPopulateStruct(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkTimeFallback benchmarks parsing a time column that consistently
+// uses a layout other than the field's format
+func BenchmarkTimeFallback(b *testing.B) {
+	reader := &CSVReader{timeLayout: DateOnly, location: time.UTC}
+	var dest time.Time
+	fieldValue := reflect.ValueOf(&dest).Elem()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := reader.setTimeValue(fieldValue, "2024-01-16T10:00:00Z", DateOnly, "date"); err != nil {
+			b.Fatalf("failed to parse time: %v", err)
+		}
+	}
+}
+
+// BenchmarkPopulateStructReuse benchmarks struct population into a single
+// destination, as in a ReadNext loop, where pointer targets are reused
+func BenchmarkPopulateStructReuse(b *testing.B) {
+	record := []string{"test_string", "123", "45.67", "true", "2024-01-01", "optional"}
+	reader := &CSVReader{
+		headerMap: map[string]int{
+			"string_field":   0,
+			"int_field":      1,
+			"float_field":    2,
+			"bool_field":     3,
+			"date_field":     4,
+			"optional_field": 5,
+		},
+		timeLayout: "2006-01-02",
+	}
+
+	var dest BenchStruct
+	destValue := reflect.ValueOf(&dest).Elem()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := reader.populateStruct(destValue, record); err != nil {
+			b.Fatalf("failed to populate struct: %v", err)
+		}
+	}
+}
+
+// BenchmarkPopulateStructUncached benchmarks struct population when the
+// field plan is rebuilt for every record, as it was before plans were cached
+func BenchmarkPopulateStructUncached(b *testing.B) {
+	record := []string{"test_string", "123", "45.67", "true", "2024-01-01", "optional"}
+	reader := &CSVReader{
+		headerMap: map[string]int{
+			"string_field":   0,
+			"int_field":      1,
+			"float_field":    2,
+			"bool_field":     3,
+			"date_field":     4,
+			"optional_field": 5,
+		},
+		timeLayout: "2006-01-02",
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var dest BenchStruct
+		reader.plans = nil
+		err := reader.populateStruct(reflect.ValueOf(&dest).Elem(), record)
+		if err != nil {
+			b.Fatalf("failed to populate struct: %v", err)
+		}
+	}
+}
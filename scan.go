@@ -0,0 +1,53 @@
+package gocsv
+
+import (
+	"io"
+	"slices"
+)
+
+// Scan advances to the next record, which is then available through
+// Record, in the style of bufio.Scanner. It returns false at the end of
+// the input or on the first error, after which Err reports the error, or
+// nil at the end of the input.
+//
+//	for reader.Scan() {
+//		fmt.Println(reader.Record())
+//	}
+//	if err := reader.Err(); err != nil {
+//		return err
+//	}
+func (r *CSVReader) Scan() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.scanRecord = nil
+	if r.scanErr != nil {
+		return false
+	}
+	record, err := r.readRecord()
+	if err == io.EOF {
+		return false
+	}
+	if err != nil {
+		r.scanErr = r.withPosition(err)
+		return false
+	}
+	r.scanRecord = slices.Clone(record)
+	return true
+}
+
+// Record returns the record read by the last call to Scan, or nil if Scan
+// returned false. The slice is not reused by later calls.
+func (r *CSVReader) Record() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.scanRecord
+}
+
+// Err returns the first error that stopped Scan, or nil if Scan stopped
+// at the end of the input
+func (r *CSVReader) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.scanErr
+}
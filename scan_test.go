@@ -0,0 +1,51 @@
+package gocsv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,note\nAlice,a\nBob,b\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var records [][]string
+	for reader.Scan() {
+		records = append(records, reader.Record())
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := [][]string{{"Alice", "a"}, {"Bob", "b"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("got %q, want %q", records, want)
+	}
+	if reader.Scan() || reader.Record() != nil {
+		t.Error("expected Scan to keep returning false at the end of the input")
+	}
+}
+
+func TestScanError(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,note\nAlice,a\nBob\nCarol,c\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	if !reader.Scan() {
+		t.Fatalf("expected a first record, got error %v", reader.Err())
+	}
+	if reader.Scan() {
+		t.Fatalf("expected Scan to stop at the short record, got %q", reader.Record())
+	}
+	var csvErr *CSVError
+	if !errors.As(reader.Err(), &csvErr) || csvErr.Line != 3 {
+		t.Errorf("expected a CSVError on line 3, got %v", reader.Err())
+	}
+	if reader.Scan() {
+		t.Error("expected Scan to keep returning false after an error")
+	}
+}
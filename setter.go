@@ -0,0 +1,72 @@
+package gocsv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// hasSetter reports whether field names a setter method in its csv tag,
+// which lets an unexported field be read through that method
+func hasSetter(field reflect.StructField) bool {
+	return parseCSVTag(field, "").setter != ""
+}
+
+// setterMethod looks up the method named by a setter tag option on a
+// pointer to structType. The method must take exactly one argument and
+// return nothing or an error.
+func setterMethod(structType reflect.Type, column, name string) (*reflect.Method, error) {
+	method, ok := reflect.PointerTo(structType).MethodByName(name)
+	if !ok {
+		return nil, &CSVError{
+			Field:   column,
+			Value:   name,
+			Type:    "setter",
+			Wrapped: fmt.Errorf("%s has no method %s", structType, name),
+		}
+	}
+
+	mt := method.Type // the receiver is the first input
+	valid := mt.NumIn() == 2 && !mt.IsVariadic() &&
+		(mt.NumOut() == 0 || mt.NumOut() == 1 && mt.Out(0) == errorType)
+	if !valid {
+		return nil, &CSVError{
+			Field:   column,
+			Value:   name,
+			Type:    "setter",
+			Wrapped: fmt.Errorf("method %s must take one argument and return nothing or an error, not %s", name, mt),
+		}
+	}
+	return &method, nil
+}
+
+// callSetter converts value to the setter's argument type and calls the
+// setter on destValue. An empty value passes the zero value so a reused
+// destination is reset. The converted argument is returned for range
+// checks.
+func (r *CSVReader) callSetter(destValue reflect.Value, fp fieldPlan, value string) (reflect.Value, error) {
+	arg := reflect.New(fp.setter.Type.In(1)).Elem()
+	if value != "" {
+		var err error
+		if fp.convert != nil {
+			err = assignConverted(arg, fp.name, value, fp.convert)
+		} else {
+			err = r.setFieldValue(arg, value, fp.tag, fp.fieldName)
+		}
+		if err != nil {
+			return arg, err
+		}
+	}
+
+	out := destValue.Addr().Method(fp.setter.Index).Call([]reflect.Value{arg})
+	if len(out) == 1 && !out[0].IsNil() {
+		return arg, &CSVError{
+			Field:   fp.name,
+			Value:   value,
+			Type:    arg.Type().String(),
+			Wrapped: out[0].Interface().(error),
+		}
+	}
+	return arg, nil
+}
@@ -0,0 +1,117 @@
+package gocsv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type account struct {
+	Name   string `csv:"name"`
+	amount float64
+	status string `csv:"status,setter=SetStatus"`
+	cents  int    `csv:"amount,setter=SetAmount,min=0"`
+}
+
+var errBadStatus = errors.New("unknown status")
+
+func (a *account) SetAmount(v int) {
+	a.cents = v
+	a.amount = float64(v) / 100
+}
+
+func (a *account) SetStatus(v string) error {
+	if v != "" && v != "open" && v != "closed" {
+		return errBadStatus
+	}
+	a.status = v
+	return nil
+}
+
+func TestSetterFields(t *testing.T) {
+	content := "name,amount,status\nAlice,1250,open\nBob,,closed\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.Prepare(account{}); err != nil {
+		t.Fatalf("unexpected error from Prepare: %v", err)
+	}
+
+	var rows []account
+	if err := reader.ReadAll(&rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].cents != 1250 || rows[0].amount != 12.5 || rows[0].status != "open" {
+		t.Errorf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].cents != 0 || rows[1].status != "closed" {
+		t.Errorf("unexpected second row: %+v", rows[1])
+	}
+
+	// Reusing a destination resets it through the setter on empty cells
+	reader, err = NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var row account
+	for reader.ReadNext(&row) == nil {
+	}
+	if row.cents != 0 || row.amount != 0 {
+		t.Errorf("expected amount reset by the setter, got %+v", row)
+	}
+}
+
+func TestSetterErrors(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,amount,status\nAlice,12,pending\nBob,-5,open\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var row account
+	err = reader.ReadNext(&row)
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || csvErr.Field != "status" || !errors.Is(err, errBadStatus) {
+		t.Errorf("expected the setter's error for status, got %v", err)
+	}
+	if err := reader.ReadNext(&row); err == nil {
+		t.Error("expected a range error for a negative amount")
+	}
+
+	// A missing or malformed setter is reported by Prepare
+	type missing struct {
+		value int `csv:"value,setter=SetValue"`
+	}
+	tests := []struct {
+		name  string
+		proto interface{}
+	}{
+		{"missing", missing{}},
+		{"two arguments", twoArgs{}},
+		{"bad result", badResult{}},
+		{"nested", struct {
+			Inner badResult `csv:"inner"`
+		}{}},
+	}
+	for _, tt := range tests {
+		err := reader.Prepare(tt.proto)
+		if !errors.As(err, &csvErr) || csvErr.Type != "setter" || csvErr.Value != "SetValue" {
+			t.Errorf("%s: expected a setter error, got %v", tt.name, err)
+		}
+	}
+}
+
+type twoArgs struct {
+	value int `csv:"value,setter=SetValue"`
+}
+
+func (t *twoArgs) SetValue(a, b int) {}
+
+type badResult struct {
+	value int `csv:"value,setter=SetValue"`
+}
+
+func (b *badResult) SetValue(v int) int { return v }
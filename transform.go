@@ -0,0 +1,50 @@
+package gocsv
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// applyTransforms normalizes a cell with the transforms listed in the
+// transform option of a tag, in order, before it is converted. Supported
+// transforms are trim, upper, lower and title.
+func applyTransforms(column, value string, transforms []string) (string, error) {
+	for _, name := range transforms {
+		switch name {
+		case "trim":
+			value = strings.TrimSpace(value)
+		case "upper":
+			value = strings.ToUpper(value)
+		case "lower":
+			value = strings.ToLower(value)
+		case "title":
+			value = titleCase(value)
+		default:
+			return "", &CSVError{
+				Field:   column,
+				Value:   name,
+				Type:    "transform",
+				Wrapped: fmt.Errorf("unknown transform %q", name),
+			}
+		}
+	}
+	return value, nil
+}
+
+// titleCase upper-cases the first letter of every word and lower-cases
+// the rest. Words are separated by white space.
+func titleCase(value string) string {
+	var b strings.Builder
+	b.Grow(len(value))
+	wordStart := true
+	for _, c := range value {
+		if wordStart {
+			b.WriteRune(unicode.ToTitle(c))
+		} else {
+			b.WriteRune(unicode.ToLower(c))
+		}
+		wordStart = unicode.IsSpace(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,44 @@
+package gocsv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	type product struct {
+		Code  string `csv:"code,,transform=upper"`
+		Email string `csv:"email,transform=trim lower"`
+		Name  string `csv:"name,notrim,transform=trim title"`
+		Qty   int    `csv:"qty,transform=trim,default=1"`
+	}
+
+	content := "code,email,name,qty\n" +
+		"ab-1, Alice@Example.COM ,  jOHN  o'neil ,\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got product
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := product{Code: "AB-1", Email: "alice@example.com", Name: "John  O'neil", Qty: 1}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	type unknown struct {
+		Code string `csv:"code,transform=reverse"`
+	}
+	reader, err = NewCSVReaderFromReader(strings.NewReader("code\nabc\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var u unknown
+	err = reader.ReadNext(&u)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Type != "transform" || csvErr.Value != "reverse" {
+		t.Errorf("expected transform CSVError for an unknown transform, got %v", err)
+	}
+}
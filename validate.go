@@ -0,0 +1,72 @@
+package gocsv
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// checkOneOf reports a non-empty cell that is not one of the values listed
+// in the oneof option of tag. Cells are compared as text after trimming.
+func checkOneOf(column, value string, tag csvTag) error {
+	if len(tag.oneOf) == 0 || slices.Contains(tag.oneOf, value) {
+		return nil
+	}
+	return &CSVError{
+		Field:   column,
+		Value:   value,
+		Type:    "enum",
+		Wrapped: fmt.Errorf("value must be one of %s", strings.Join(tag.oneOf, ", ")),
+	}
+}
+
+// checkRange reports a decoded int, uint or float field that falls outside
+// the min and max options of tag. Other kinds are not checked.
+func checkRange(column, value string, fieldValue reflect.Value, tag csvTag) error {
+	if !tag.hasMin && !tag.hasMax {
+		return nil
+	}
+	for fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+
+	var number float64
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number = float64(fieldValue.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number = float64(fieldValue.Uint())
+	case reflect.Float32, reflect.Float64:
+		number = fieldValue.Float()
+	default:
+		return nil
+	}
+
+	var err error
+	switch {
+	case tag.hasMin && math.IsNaN(tag.min), tag.hasMax && math.IsNaN(tag.max):
+		err = fmt.Errorf("invalid min or max option")
+	case tag.hasMin && number < tag.min:
+		err = fmt.Errorf("value is below the minimum %s", formatBound(tag.min))
+	case tag.hasMax && number > tag.max:
+		err = fmt.Errorf("value is above the maximum %s", formatBound(tag.max))
+	default:
+		return nil
+	}
+	return &CSVError{
+		Field:   column,
+		Value:   value,
+		Type:    "range",
+		Wrapped: err,
+	}
+}
+
+func formatBound(bound float64) string {
+	return strconv.FormatFloat(bound, 'g', -1, 64)
+}
@@ -0,0 +1,92 @@
+package gocsv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOneOf(t *testing.T) {
+	type order struct {
+		ID     int    `csv:"id"`
+		Status string `csv:"status,oneof=new paid refunded"`
+		Level  int    `csv:"level,oneof=1 2 3"`
+	}
+
+	content := "id,status,level\n" +
+		"1, paid ,2\n" +
+		"2,,\n" +
+		"3,shipped,1\n" +
+		"4,new,7\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got order
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != "paid" || got.Level != 2 {
+		t.Errorf("unexpected value: %+v", got)
+	}
+	if err := reader.ReadNext(&got); err != nil {
+		t.Errorf("expected empty cells to skip the check, got %v", err)
+	}
+
+	err = reader.ReadNext(&got)
+	csvErr, ok := err.(*CSVError)
+	if !ok || csvErr.Type != "enum" || csvErr.Field != "status" || csvErr.Value != "shipped" || csvErr.Line != 4 {
+		t.Errorf("expected enum CSVError for shipped on line 4, got %v", err)
+	}
+
+	err = reader.ReadNext(&got)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Type != "enum" || csvErr.Field != "level" {
+		t.Errorf("expected enum CSVError for level, got %v", err)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	type person struct {
+		Age    int      `csv:"age,,min=0,max=120"`
+		Score  *float64 `csv:"score,min=-1.5,max=1.5"`
+		Visits uint     `csv:"visits,max=10"`
+	}
+
+	content := "age,score,visits\n" +
+		"0,1.5,10\n" +
+		"121,0,0\n" +
+		"30,-2,0\n" +
+		"30,0,11\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var got person
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("unexpected error for values on the bounds: %v", err)
+	}
+
+	for _, want := range []struct {
+		field, value string
+		line         int
+	}{{"age", "121", 3}, {"score", "-2", 4}, {"visits", "11", 5}} {
+		err := reader.ReadNext(&got)
+		csvErr, ok := err.(*CSVError)
+		if !ok || csvErr.Type != "range" || csvErr.Field != want.field || csvErr.Value != want.value || csvErr.Line != want.line {
+			t.Errorf("expected range CSVError for %s=%s on line %d, got %v", want.field, want.value, want.line, err)
+		}
+	}
+
+	type invalid struct {
+		Age int `csv:"age,min=zero"`
+	}
+	reader, err = NewCSVReaderFromReader(strings.NewReader("age\n5\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	var bad invalid
+	if err := reader.ReadNext(&bad); err == nil {
+		t.Error("expected error for an invalid min option, got nil")
+	}
+}
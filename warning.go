@@ -0,0 +1,93 @@
+package gocsv
+
+import "fmt"
+
+// WarningKind identifies what a Warning reports
+type WarningKind int
+
+const (
+	// WarningUnmappedColumn reports a header that no field of the
+	// destination struct reads, a sign of schema drift
+	WarningUnmappedColumn WarningKind = iota + 1
+	// WarningEmptyCell reports an empty cell whose field was left at its
+	// zero value
+	WarningEmptyCell
+)
+
+// String returns a short name for the kind
+func (k WarningKind) String() string {
+	switch k {
+	case WarningUnmappedColumn:
+		return "unmapped column"
+	case WarningEmptyCell:
+		return "empty cell"
+	}
+	return fmt.Sprintf("WarningKind(%d)", int(k))
+}
+
+// Warning is a non-fatal observation passed to the handler set with
+// SetWarningHandler. Row and Line are zero for warnings about the header.
+type Warning struct {
+	Kind   WarningKind
+	Column string
+	Row    int
+	Line   int
+}
+
+// String formats the warning like a CSVError message
+func (w Warning) String() string {
+	if w.Line > 0 {
+		return fmt.Sprintf("line %d: %s %q", w.Line, w.Kind, w.Column)
+	}
+	return fmt.Sprintf("%s %q", w.Kind, w.Column)
+}
+
+// SetWarningHandler registers fn to be told about data that is silently
+// dropped: headers no struct field maps to, reported once per destination
+// type when it is first read, and empty cells read as zero values. Columns
+// excluded with SelectColumns are not reported. fn runs while the reader
+// is locked, so it must not call methods of the reader. A nil fn turns
+// warnings off.
+func (r *CSVReader) SetWarningHandler(fn func(Warning)) {
+	r.mu.Lock()
+	r.warn = fn
+	r.plans = nil
+	r.warned = nil
+	r.mu.Unlock()
+}
+
+// emit passes w to the warning handler, filling in the current position
+func (r *CSVReader) emit(w Warning) {
+	if r.warn == nil {
+		return
+	}
+	w.Row, w.Line = r.rows, r.line
+	r.warn(w)
+}
+
+// warnUnmapped reports the headers that no field of fields reads
+func (r *CSVReader) warnUnmapped(fields []fieldPlan) {
+	if r.noHeader {
+		return
+	}
+	mapped := make(map[int]bool, len(r.headers))
+	markMapped(fields, mapped)
+	for i, header := range r.headers {
+		if mapped[i] || (r.selected != nil && !r.selected[i]) {
+			continue
+		}
+		r.warn(Warning{Kind: WarningUnmappedColumn, Column: header})
+	}
+}
+
+func markMapped(fields []fieldPlan, mapped map[int]bool) {
+	for _, fp := range fields {
+		markMapped(fp.nested, mapped)
+		for _, wc := range fp.wildcard {
+			mapped[wc.column] = true
+		}
+		if fp.column >= 0 && !fp.isNested && !fp.isMap {
+			mapped[fp.column] = true
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package gocsv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetWarningHandler(t *testing.T) {
+	content := "name,age,nickname,city\nAlice,30,Al,Paris\nBob,,Bobby,Lyon\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	if err := reader.SelectColumns("name", "age", "nickname"); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []Warning
+	reader.SetWarningHandler(func(w Warning) {
+		warnings = append(warnings, w)
+	})
+
+	var rows []struct {
+		Name string `csv:"name"`
+		Age  int    `csv:"age"`
+	}
+	if err := reader.ReadAll(&rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// city is excluded by SelectColumns, so only nickname is unmapped
+	want := []Warning{
+		{Kind: WarningUnmappedColumn, Column: "nickname"},
+		{Kind: WarningEmptyCell, Column: "age", Row: 2, Line: 3},
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("got %+v, want %+v", warnings, want)
+	}
+	if got := warnings[1].String(); got != `line 3: empty cell "age"` {
+		t.Errorf("unexpected message: %s", got)
+	}
+}
+
+func TestWarningHandlerUnset(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("name,extra\n,x\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	called := false
+	reader.SetWarningHandler(func(Warning) { called = true })
+	reader.SetWarningHandler(nil)
+
+	var row struct {
+		Name string `csv:"name"`
+	}
+	if err := reader.ReadNext(&row); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if called {
+		t.Error("expected no warnings after removing the handler")
+	}
+}
+
+func TestUnmappedWarningOnce(t *testing.T) {
+	content := "name,extra\nAlice,x\nBob,y\nCarol,z\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	unmapped := 0
+	reader.SetWarningHandler(func(w Warning) {
+		if w.Kind == WarningUnmappedColumn {
+			unmapped++
+		}
+	})
+
+	type person struct {
+		Name string `csv:"name"`
+	}
+	var row person
+	if err := reader.ReadNext(&row); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Both of these rebuild the plan for person
+	reader.SetEmptyAsError("name")
+	if err := reader.ReadNext(&row); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rest []person
+	if err := reader.ReadAllWithLayout(&rest, DateOnly); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unmapped != 1 {
+		t.Errorf("expected one unmapped column warning, got %d", unmapped)
+	}
+}
@@ -0,0 +1,93 @@
+package gocsv
+
+import (
+	"reflect"
+	"strings"
+)
+
+// wildcardColumn is one column gathered into a map field by a wildcard tag
+type wildcardColumn struct {
+	column int
+	key    string
+}
+
+// isWildcard reports whether a tag name such as "metric_*" gathers every
+// column starting with the text before the asterisk
+func isWildcard(name string) bool {
+	return strings.HasSuffix(name, "*")
+}
+
+// isWildcardMap reports whether t is a map type a wildcard tag can fill:
+// string keys and any value type setFieldValue handles
+func isWildcardMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+// wildcardColumns returns the columns whose header starts with the prefix
+// of pattern, keyed by the rest of the header. Readers without a header
+// have no names to match.
+func (r *CSVReader) wildcardColumns(pattern string) []wildcardColumn {
+	if r.noHeader {
+		return nil
+	}
+	prefix := strings.TrimSuffix(pattern, "*")
+	if r.foldCase {
+		prefix = normalizeHeader(prefix)
+	}
+
+	var columns []wildcardColumn
+	for i, header := range r.headers {
+		name := header
+		if r.foldCase {
+			name = normalizeHeader(header)
+		}
+		key, ok := strings.CutPrefix(name, prefix)
+		if !ok || (r.selected != nil && !r.selected[i]) {
+			continue
+		}
+		columns = append(columns, wildcardColumn{column: i, key: key})
+	}
+	return columns
+}
+
+// populateMap replaces the map field of fp with the cells of its wildcard
+// columns. Each value is converted like a field of the map's element type;
+// empty cells leave their key out. A fresh map is set on every row so
+// reused destinations do not keep keys from earlier rows.
+func (r *CSVReader) populateMap(fieldValue reflect.Value, record []string, fp fieldPlan) error {
+	mapType := fieldValue.Type()
+	m := reflect.MakeMapWithSize(mapType, len(fp.wildcard))
+	for _, wc := range fp.wildcard {
+		if wc.column >= len(record) {
+			if r.variable {
+				continue
+			}
+			return &CSVError{Field: r.headers[wc.column], Value: "index out of range"}
+		}
+
+		header := r.headers[wc.column]
+		value := r.trim(record[wc.column], fp.tag)
+		if len(fp.tag.transforms) > 0 {
+			var err error
+			if value, err = applyTransforms(header, value, fp.tag.transforms); err != nil {
+				return err
+			}
+		}
+		if len(r.nullValues) > 0 && r.nullValues[strings.ToLower(value)] {
+			value = ""
+		}
+		if value == "" {
+			r.emit(Warning{Kind: WarningEmptyCell, Column: header})
+			continue
+		}
+
+		elem := reflect.New(mapType.Elem()).Elem()
+		// Conversion errors name the column rather than the map field
+		if err := r.setFieldValue(elem, value, fp.tag, header); err != nil {
+			return err
+		}
+		m.SetMapIndex(reflect.ValueOf(wc.key).Convert(mapType.Key()), elem)
+	}
+	fieldValue.Set(m)
+	return nil
+}
@@ -0,0 +1,77 @@
+package gocsv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type wildcardRow struct {
+	Host    string             `csv:"host"`
+	Metrics map[string]float64 `csv:"metric_*"`
+	Tags    map[string]string  `csv:"tag_*"`
+}
+
+func TestWildcardMapFields(t *testing.T) {
+	content := "host,metric_cpu,metric_mem,tag_env,metric_disk\n" +
+		"a,0.5,12,prod,3\n" +
+		"b,,7, ,\n"
+	reader, err := NewCSVReaderFromReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var row wildcardRow
+	if err := reader.ReadNext(&row); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantMetrics := map[string]float64{"cpu": 0.5, "mem": 12, "disk": 3}
+	if row.Host != "a" || !reflect.DeepEqual(row.Metrics, wantMetrics) {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	if !reflect.DeepEqual(row.Tags, map[string]string{"env": "prod"}) {
+		t.Errorf("unexpected tags: %v", row.Tags)
+	}
+
+	// Empty cells leave their key out rather than keeping the earlier value
+	if err := reader.ReadNext(&row); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(row.Metrics, map[string]float64{"mem": 7}) {
+		t.Errorf("unexpected metrics: %v", row.Metrics)
+	}
+	if len(row.Tags) != 0 {
+		t.Errorf("expected no tags, got %v", row.Tags)
+	}
+}
+
+func TestWildcardMapConversionError(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("host,metric_cpu\na,high\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+
+	var row wildcardRow
+	err = reader.ReadNext(&row)
+	var csvErr *CSVError
+	if !errors.As(err, &csvErr) || csvErr.Field != "metric_cpu" || csvErr.Value != "high" {
+		t.Errorf("expected a CSVError for metric_cpu, got %v", err)
+	}
+}
+
+func TestWildcardMapStrict(t *testing.T) {
+	reader, err := NewCSVReaderFromReader(strings.NewReader("host\na\n"))
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	reader.StrictColumns(true)
+
+	var row wildcardRow
+	if err := reader.ReadNext(&row); err != nil {
+		t.Fatalf("a wildcard without matches should not be missing: %v", err)
+	}
+	if row.Metrics == nil || len(row.Metrics) != 0 {
+		t.Errorf("expected an empty map, got %v", row.Metrics)
+	}
+}
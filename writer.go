@@ -0,0 +1,570 @@
+package gocsv
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+type CSVWriter struct {
+	writer      *csv.Writer
+	file        *os.File
+	skipHeader  bool
+	timeLayout  string
+	sliceSep    string
+	columns     []string
+	projections map[reflect.Type][]int
+	flushEvery  int
+	unflushed   int // rows written since the last periodic flush
+	mu          sync.RWMutex
+}
+
+// NewCSVWriter creates a new CSV writer that writes to w
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{
+		writer:     csv.NewWriter(w),
+		timeLayout: DateOnly, // Default layout
+		sliceSep:   DefaultSliceSeparator,
+	}
+}
+
+// NewCSVWriterAppend creates a CSV writer that appends to the file at
+// filePath, creating it if needed, so rows can be added across runs. If
+// the file already has content its header is assumed to be present and
+// WriteHeader does nothing. Close flushes the writer and closes the file.
+func NewCSVWriterAppend(filePath string) (*CSVWriter, error) {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, &CSVError{Field: "file", Value: filePath, Wrapped: err}
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, &CSVError{Field: "file", Value: filePath, Wrapped: err}
+	}
+
+	w := NewCSVWriter(file)
+	w.file = file
+	if info.Size() == 0 {
+		return w, nil
+	}
+	w.skipHeader = true
+
+	// Terminate a last line written without a newline so the first new
+	// record does not run into it
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		file.Close()
+		return nil, &CSVError{Field: "file", Value: filePath, Wrapped: err}
+	}
+	if last[0] != '\n' {
+		if _, err := file.Write([]byte("\n")); err != nil {
+			file.Close()
+			return nil, &CSVError{Field: "file", Value: filePath, Wrapped: err}
+		}
+	}
+	return w, nil
+}
+
+func (w *CSVWriter) SetTimeLayout(layout string) error {
+	if err := validateTimeLayout(layout); err != nil {
+		return &CSVError{
+			Field:   "timeLayout",
+			Value:   layout,
+			Type:    "string",
+			Wrapped: err,
+		}
+	}
+	w.mu.Lock()
+	w.timeLayout = layout
+	w.mu.Unlock()
+	return nil
+}
+
+// SetSliceSeparator sets the separator used to join the elements of slice
+// fields into a single cell
+func (w *CSVWriter) SetSliceSeparator(sep string) error {
+	if sep == "" {
+		return &CSVError{
+			Field:   "sliceSeparator",
+			Type:    "string",
+			Wrapped: fmt.Errorf("slice separator cannot be empty"),
+		}
+	}
+	w.mu.Lock()
+	w.sliceSep = sep
+	w.mu.Unlock()
+	return nil
+}
+
+// SetFlushEvery makes Write flush every n rows, so a long export streams
+// to the underlying writer, such as an HTTP response, as it goes and a
+// failed write is reported by the Write that triggered the flush. Zero, the
+// default, leaves flushing to Flush, WriteAll and Close.
+func (w *CSVWriter) SetFlushEvery(n int) error {
+	if n < 0 {
+		return &CSVError{
+			Field:   "flushEvery",
+			Value:   strconv.Itoa(n),
+			Type:    "int",
+			Wrapped: fmt.Errorf("flush interval cannot be negative"),
+		}
+	}
+	w.mu.Lock()
+	w.flushEvery = n
+	w.unflushed = 0
+	w.mu.Unlock()
+	return nil
+}
+
+// SetOutputColumns limits the written columns to names, in that order,
+// regardless of the order of the struct fields. Names are the column names
+// written in the header, such as "address.city" for nested structs.
+// Writing a struct that has no column for one of the names fails with a
+// CSVError wrapping ErrColumnMissing. Calling it without names writes
+// every column again.
+func (w *CSVWriter) SetOutputColumns(names ...string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" || seen[name] {
+			return &CSVError{
+				Field:   "columns",
+				Value:   name,
+				Type:    "string",
+				Wrapped: fmt.Errorf("output columns must be unique and not empty"),
+			}
+		}
+		seen[name] = true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.columns = nil
+	if len(names) > 0 {
+		w.columns = append([]string(nil), names...)
+	}
+	w.projections = nil
+	return nil
+}
+
+// projection returns the index in the full record of srcType of each
+// output column, or nil when every column is written. It also rejects
+// struct types the writer cannot lay out.
+func (w *CSVWriter) projection(srcType reflect.Type) ([]int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if p, ok := w.projections[srcType]; ok {
+		return p, nil
+	}
+
+	names := w.headerNames(srcType, "", nil, nil)
+	for _, name := range names {
+		// An @N field is read from column N whatever the header says,
+		// which a header derived from field order cannot promise
+		if _, ok := parseAbsolutePosition(name); ok {
+			return nil, &CSVError{
+				Field:   name,
+				Wrapped: fmt.Errorf("%s: absolute column positions can only be read", srcType),
+			}
+		}
+	}
+
+	var p []int
+	if w.columns != nil {
+		index := make(map[string]int)
+		for i, name := range names {
+			index[name] = i
+		}
+		p = make([]int, len(w.columns))
+		for i, name := range w.columns {
+			column, ok := index[name]
+			if !ok {
+				return nil, &CSVError{
+					Field:   "columns",
+					Value:   name,
+					Type:    srcType.String(),
+					Wrapped: fmt.Errorf("%w: %s", ErrColumnMissing, name),
+				}
+			}
+			p[i] = column
+		}
+	}
+	if w.projections == nil {
+		w.projections = make(map[reflect.Type][]int)
+	}
+	w.projections[srcType] = p
+	return p, nil
+}
+
+// project reorders record following p
+func project(record []string, p []int) []string {
+	if p == nil {
+		return record
+	}
+	projected := make([]string, len(p))
+	for i, column := range p {
+		projected[i] = record[column]
+	}
+	return projected
+}
+
+// WriteHeader writes the column names derived from the csv tags of src,
+// limited and ordered by SetOutputColumns if it was called. It does nothing
+// when appending to a file that already has content.
+func (w *CSVWriter) WriteHeader(src interface{}) error {
+	srcValue, err := structValue(src)
+	if err != nil {
+		return err
+	}
+	if w.skipHeader {
+		return nil
+	}
+
+	p, err := w.projection(srcValue.Type())
+	if err != nil {
+		return err
+	}
+	return w.writer.Write(project(w.headerNames(srcValue.Type(), "", nil, nil), p))
+}
+
+func (w *CSVWriter) headerNames(srcType reflect.Type, prefix string, path []reflect.Type, header []string) []string {
+	path = append(path, srcType)
+	for i := 0; i < srcType.NumField(); i++ {
+		field := srcType.Field(i)
+		embedded := isEmbeddedStruct(field)
+		if !embedded && !field.IsExported() {
+			continue
+		}
+		if isRecursive(field, path) {
+			continue
+		}
+
+		tag := parseCSVTag(field, w.timeLayout)
+		if !embedded && tag.name == "-" {
+			continue
+		}
+		if isWildcard(tag.name) && isWildcardMap(field.Type) {
+			// Wildcard map keys vary by row, so they have no fixed columns
+			continue
+		}
+
+		if embedded {
+			header = w.headerNames(indirectType(field.Type), prefix, path, header)
+			continue
+		}
+		if isNestedStruct(field) {
+			header = w.headerNames(indirectType(field.Type), prefix+tag.name+".", path, header)
+			continue
+		}
+		header = append(header, prefix+tag.name)
+	}
+	return header
+}
+
+// Write writes the fields of src as a single record, limited and ordered
+// by SetOutputColumns if it was called
+func (w *CSVWriter) Write(src interface{}) error {
+	srcValue, err := structValue(src)
+	if err != nil {
+		return err
+	}
+
+	p, err := w.projection(srcValue.Type())
+	if err != nil {
+		return err
+	}
+	record, err := w.buildRecord(srcValue, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := w.writer.Write(project(record, p)); err != nil {
+		return err
+	}
+	if w.flushEvery > 0 {
+		w.unflushed++
+		if w.unflushed >= w.flushEvery {
+			w.unflushed = 0
+			return w.Flush()
+		}
+	}
+	return nil
+}
+
+// WriteAll writes a header derived from the element type of src followed
+// by one record per element, then flushes. src must be a slice of structs
+// or struct pointers, or a pointer to such a slice.
+func (w *CSVWriter) WriteAll(src interface{}) error {
+	srcValue := reflect.ValueOf(src)
+	if srcValue.Kind() == reflect.Ptr && !srcValue.IsNil() {
+		srcValue = srcValue.Elem()
+	}
+	if srcValue.Kind() != reflect.Slice || indirectType(srcValue.Type().Elem()).Kind() != reflect.Struct {
+		return &CSVError{Field: "source", Type: "slice of structs",
+			Value: fmt.Sprintf("%T", src), Wrapped: ErrNotStruct}
+	}
+
+	elemType := indirectType(srcValue.Type().Elem())
+	if err := w.WriteHeader(reflect.New(elemType).Interface()); err != nil {
+		return err
+	}
+	for i := 0; i < srcValue.Len(); i++ {
+		if err := w.Write(srcValue.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+func (w *CSVWriter) buildRecord(srcValue reflect.Value, path []reflect.Type, record []string) ([]string, error) {
+	srcType := srcValue.Type()
+	path = append(path, srcType)
+
+	for i := 0; i < srcType.NumField(); i++ {
+		field := srcType.Field(i)
+		fieldValue := srcValue.Field(i)
+
+		embedded := isEmbeddedStruct(field)
+		if !embedded && !field.IsExported() {
+			continue
+		}
+		if isRecursive(field, path) {
+			continue
+		}
+
+		tag := parseCSVTag(field, w.timeLayout)
+		if !embedded && tag.name == "-" {
+			continue
+		}
+		if isWildcard(tag.name) && isWildcardMap(field.Type) {
+			// Wildcard map keys vary by row, so they have no fixed columns
+			continue
+		}
+
+		if embedded || isNestedStruct(field) {
+			if fieldValue.Kind() == reflect.Ptr {
+				// A nil pointer leaves all of the struct's columns empty
+				if fieldValue.IsNil() {
+					columns := len(w.headerNames(field.Type.Elem(), "", path, nil))
+					record = append(record, make([]string, columns)...)
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+
+			var err error
+			record, err = w.buildRecord(fieldValue, path, record)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// The cell is still written so the row stays aligned with the header
+		if tag.omitEmpty && fieldValue.IsZero() {
+			record = append(record, "")
+			continue
+		}
+
+		value, err := w.formatFieldValue(fieldValue, tag, field.Name)
+		if err != nil {
+			return nil, err
+		}
+		record = append(record, value)
+	}
+
+	return record, nil
+}
+
+func (w *CSVWriter) formatFieldValue(fieldValue reflect.Value, tag csvTag, fieldName string) (string, error) {
+	// Handle pointer types, nil pointers become empty cells
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return "", nil
+		}
+		return w.formatFieldValue(fieldValue.Elem(), tag, fieldName)
+	}
+
+	// Handle fields stored as JSON in a single cell
+	if tag.json {
+		data, err := json.Marshal(fieldValue.Interface())
+		if err != nil {
+			return "", &CSVError{
+				Field:   strings.ToLower(fieldName),
+				Type:    "json",
+				Wrapped: err,
+			}
+		}
+		return string(data), nil
+	}
+
+	// Handle time.Time
+	if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
+		t := fieldValue.Interface().(time.Time)
+		switch tag.timeFormat {
+		case "unix":
+			return strconv.FormatInt(t.Unix(), 10), nil
+		case "unixmilli":
+			return strconv.FormatInt(t.UnixMilli(), 10), nil
+		}
+		return t.Format(tag.timeFormat), nil
+	}
+
+	// Handle types that know how to render themselves, preferring
+	// encoding.TextMarshaler over fmt.Stringer
+	if marshaler, ok := methodReceiver(fieldValue, textMarshalerType); ok {
+		text, err := marshaler.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", &CSVError{
+				Field:   strings.ToLower(fieldName),
+				Type:    fieldValue.Type().String(),
+				Wrapped: err,
+			}
+		}
+		return string(text), nil
+	}
+	if stringer, ok := methodReceiver(fieldValue, stringerType); ok {
+		return stringer.Interface().(fmt.Stringer).String(), nil
+	}
+
+	// Handle basic types
+	switch fieldValue.Kind() {
+	case reflect.String:
+		return fieldValue.String(), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return formatNumber(strconv.FormatInt(fieldValue.Int(), formatBase(tag.base)), tag, false), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return formatNumber(strconv.FormatUint(fieldValue.Uint(), formatBase(tag.base)), tag, false), nil
+
+	case reflect.Float32, reflect.Float64:
+		text := strconv.FormatFloat(fieldValue.Float(), 'f', -1, fieldValue.Type().Bits())
+		if shifted, ok := shiftDecimal(text, 2); ok && tag.percent {
+			text = shifted
+		}
+		return formatNumber(text, tag, true), nil
+
+	case reflect.Complex64, reflect.Complex128:
+		return strconv.FormatComplex(fieldValue.Complex(), 'f', -1, fieldValue.Type().Bits()), nil
+
+	case reflect.Bool:
+		return strconv.FormatBool(fieldValue.Bool()), nil
+
+	case reflect.Slice:
+		if !isSplitSlice(fieldValue) {
+			text, err := encodeBytes(fieldValue.Bytes(), tag.encoding)
+			if err != nil {
+				return "", &CSVError{
+					Field:   strings.ToLower(fieldName),
+					Type:    "[]byte",
+					Wrapped: err,
+				}
+			}
+			return text, nil
+		}
+		return w.joinElements(fieldValue, tag, fieldName)
+
+	case reflect.Array:
+		return w.joinElements(fieldValue, tag, fieldName)
+	}
+
+	return "", &CSVError{
+		Field: strings.ToLower(fieldName),
+		Value: fmt.Sprintf("%v", fieldValue.Interface()),
+		Type:  fieldValue.Kind().String(),
+	}
+}
+
+// joinElements formats the elements of a slice or array field into one
+// cell, separated by the slice separator
+func (w *CSVWriter) joinElements(fieldValue reflect.Value, tag csvTag, fieldName string) (string, error) {
+	parts := make([]string, fieldValue.Len())
+	for i := range parts {
+		part, err := w.formatFieldValue(fieldValue.Index(i), tag, fieldName)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, w.sliceSep), nil
+}
+
+// formatBase returns the base integers are written in. Integers read with
+// base=0 are auto-detected, so they are written in decimal.
+func formatBase(base int) int {
+	if base < 2 || base > 36 {
+		return 10
+	}
+	return base
+}
+
+// methodReceiver returns fieldValue, or its address when the methods of
+// iface are declared on the pointer receiver
+func methodReceiver(fieldValue reflect.Value, iface reflect.Type) (reflect.Value, bool) {
+	if fieldValue.Type().Implements(iface) {
+		return fieldValue, true
+	}
+	if fieldValue.CanAddr() && fieldValue.Addr().Type().Implements(iface) {
+		return fieldValue.Addr(), true
+	}
+	return reflect.Value{}, false
+}
+
+// Flush writes any buffered data to the underlying writer
+func (w *CSVWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// Close flushes the writer and closes the file opened by
+// NewCSVWriterAppend, if any
+func (w *CSVWriter) Close() error {
+	err := w.Flush()
+	if w.file != nil {
+		err = errors.Join(err, w.file.Close())
+	}
+	return err
+}
+
+// structValue dereferences src and ensures it is a struct
+func structValue(src interface{}) (reflect.Value, error) {
+	srcValue := reflect.ValueOf(src)
+	for srcValue.Kind() == reflect.Ptr {
+		if srcValue.IsNil() {
+			return reflect.Value{}, &CSVError{Field: "source", Type: "struct",
+				Value: fmt.Sprintf("%T", src), Wrapped: ErrNotPointer}
+		}
+		srcValue = srcValue.Elem()
+	}
+
+	if srcValue.Kind() != reflect.Struct {
+		return reflect.Value{}, &CSVError{Field: "source", Type: "struct",
+			Value: fmt.Sprintf("%T", src), Wrapped: ErrNotStruct}
+	}
+
+	// Copy structs passed by value so pointer receiver methods can be used
+	if !srcValue.CanAddr() {
+		addressable := reflect.New(srcValue.Type()).Elem()
+		addressable.Set(srcValue)
+		srcValue = addressable
+	}
+
+	return srcValue, nil
+}
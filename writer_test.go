@@ -0,0 +1,619 @@
+package gocsv
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCSVWriterWriteHeader(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+
+	if err := writer.WriteHeader(TestStruct{}); err != nil {
+		t.Fatalf("failed to write header: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "string_field,int_field,float_field,bool_field,date_field,optional_field\n"
+	if buf.String() != expected {
+		t.Errorf("header: got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      interface{}
+		expected string
+	}{
+		{
+			name: "struct value",
+			src: TestStruct{
+				StringField: "value1",
+				IntField:    123,
+				FloatField:  45.67,
+				BoolField:   true,
+				DateField:   mustParseTime("2024-01-01"),
+				OptionalPtr: strPtr("optional"),
+			},
+			expected: "value1,123,45.67,true,2024-01-01,optional\n",
+		},
+		{
+			name: "pointer with nil field",
+			src: &TestStruct{
+				StringField: "value2",
+				IntField:    -456,
+				FloatField:  78.9,
+				DateField:   mustParseTime("2024-02-01"),
+			},
+			expected: "value2,-456,78.9,false,2024-02-01,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer := NewCSVWriter(&buf)
+
+			if err := writer.Write(tt.src); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("failed to close: %v", err)
+			}
+
+			if buf.String() != tt.expected {
+				t.Errorf("got %q, want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestCSVWriterInvalidSource(t *testing.T) {
+	writer := NewCSVWriter(&bytes.Buffer{})
+	if err := writer.Write("not a struct"); err == nil {
+		t.Error("expected error, got nil")
+	}
+	if err := writer.Write((*TestStruct)(nil)); err == nil {
+		t.Error("expected error for nil pointer, got nil")
+	}
+}
+
+func TestCSVWriterRoundTrip(t *testing.T) {
+	expected := TestStruct{
+		StringField: "value1",
+		IntField:    123,
+		FloatField:  45.67,
+		BoolField:   true,
+		DateField:   mustParseTime("2024-01-01"),
+		OptionalPtr: strPtr("optional"),
+	}
+
+	tmpFile := filepath.Join(t.TempDir(), "roundtrip.csv")
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+
+	writer := NewCSVWriter(file)
+	if err := writer.WriteHeader(expected); err != nil {
+		t.Fatalf("failed to write header: %v", err)
+	}
+	if err := writer.Write(expected); err != nil {
+		t.Fatalf("failed to write record: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	file.Close()
+
+	reader, err := NewCSVReader(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	defer reader.Close()
+
+	var got TestStruct
+	if err := reader.ReadNext(&got); err != nil {
+		t.Fatalf("failed to read record: %v", err)
+	}
+
+	if got.StringField != expected.StringField || got.IntField != expected.IntField ||
+		got.FloatField != expected.FloatField || got.BoolField != expected.BoolField ||
+		!got.DateField.Equal(expected.DateField) || got.OptionalPtr == nil ||
+		*got.OptionalPtr != *expected.OptionalPtr {
+		t.Errorf("round trip mismatch: got %+v, want %+v", got, expected)
+	}
+}
+
+type level int
+
+func (l level) String() string {
+	return [...]string{"low", "high"}[l]
+}
+
+func TestCSVWriterMarshalers(t *testing.T) {
+	type host struct {
+		Addr  net.IP `csv:"addr"`
+		Level level  `csv:"level"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	if err := writer.Write(host{Addr: net.ParseIP("10.0.0.1"), Level: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "10.0.0.1,high\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterEmbeddedStructs(t *testing.T) {
+	type account struct {
+		Name string `csv:"name"`
+		*AuditFields
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	writer.WriteHeader(account{})
+	writer.Write(account{Name: "alice", AuditFields: &AuditFields{
+		CreatedAt: mustParseTime("2024-01-01"),
+		UpdatedBy: "bob",
+	}})
+	writer.Write(account{Name: "carol"})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "name,created_at,updated_by\nalice,2024-01-01,bob\ncarol,,\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterNestedStructs(t *testing.T) {
+	type customer struct {
+		Name     string   `csv:"name"`
+		Address  Address  `csv:"address"`
+		Shipping *Address `csv:"shipping"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	writer.WriteHeader(customer{})
+	writer.Write(customer{Name: "alice", Address: Address{City: "Paris", Zip: "75001"}})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "name,address.city,address.zip,shipping.city,shipping.zip\nalice,Paris,75001,,\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterRecursiveStructs(t *testing.T) {
+	rows := []treeNode{
+		{Name: "a", Next: &treeNode{Name: "b"}, Leaf: &treeLeaf{Value: 1}},
+		{Name: "c"},
+	}
+	data, err := Marshal(rows)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "name,leaf.value\na,1\nc,\n"
+	if string(data) != expected {
+		t.Errorf("got %q, want %q", data, expected)
+	}
+}
+
+func TestCSVWriterSliceFields(t *testing.T) {
+	type post struct {
+		Tags   []string `csv:"tags"`
+		Scores []int    `csv:"scores"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	writer.Write(post{Tags: []string{"go", "csv"}, Scores: []int{1, 2}})
+	if err := writer.SetSliceSeparator("|"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writer.Write(post{Tags: []string{"a", "b"}})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "go;csv,1;2\na|b,\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterAbsolutePositions(t *testing.T) {
+	type entry struct {
+		Name  string `csv:"name"`
+		Third string `csv:"@2"`
+	}
+
+	if _, err := Marshal([]entry{{Name: "bob", Third: "y"}}); err == nil || err.Error() != "field @2: gocsv.entry: absolute column positions can only be read" {
+		t.Errorf("expected @2 to be rejected, got %v", err)
+	}
+	writer := NewCSVWriter(&bytes.Buffer{})
+	if err := writer.Write(entry{Name: "bob"}); err == nil {
+		t.Error("expected Write to reject @2")
+	}
+}
+
+func TestCSVWriterWildcardMaps(t *testing.T) {
+	type host struct {
+		Name    string             `csv:"host"`
+		Metrics map[string]float64 `csv:"metric_*"`
+	}
+
+	data, err := Marshal([]host{{Name: "web", Metrics: map[string]float64{"cpu": 1}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "host\nweb\n"
+	if string(data) != expected {
+		t.Errorf("got %q, want %q", data, expected)
+	}
+}
+
+func TestCSVWriterArrayFields(t *testing.T) {
+	type pixel struct {
+		RGB [3]int `csv:"rgb"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	if err := writer.Write(pixel{RGB: [3]int{10, 20, 30}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	if expected := "10;20;30\n"; buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterOmitEmpty(t *testing.T) {
+	type person struct {
+		First  string    `csv:"first_name"`
+		Middle string    `csv:"middle_name,omitempty"`
+		Age    int       `csv:"age,omitempty"`
+		Score  *int      `csv:"score,omitempty"`
+		Joined time.Time `csv:"joined,2006-01-02,omitempty"`
+		Active bool      `csv:"active"`
+	}
+
+	zero := 0
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	writer.WriteHeader(person{})
+	writer.Write(person{First: "alice"})
+	writer.Write(person{First: "bob", Middle: "j", Age: 30, Score: &zero, Joined: mustParseTime("2024-01-01"), Active: true})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "first_name,middle_name,age,score,joined,active\n" +
+		"alice,,,,,false\n" +
+		"bob,j,30,0,2024-01-01,true\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+
+	tag := parseCSVTag(reflect.TypeOf(person{}).Field(1), DateOnly)
+	if tag.name != "middle_name" || tag.timeFormat != DateOnly || !tag.omitEmpty {
+		t.Errorf("unexpected tag %+v", tag)
+	}
+}
+
+func TestCSVWriterComplexAndBig(t *testing.T) {
+	type measurement struct {
+		Impedance complex128 `csv:"impedance"`
+		Count     *big.Int   `csv:"count"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	writer.Write(measurement{Impedance: complex(3, -4), Count: big.NewInt(42)})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "(3-4i),42\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterByteSlices(t *testing.T) {
+	type blob struct {
+		Raw     []byte `csv:"raw"`
+		Payload []byte `csv:"payload,encoding=base64"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	writer.Write(blob{Raw: []byte("hello"), Payload: []byte("hello world")})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "hello,aGVsbG8gd29ybGQ=\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterJSONFields(t *testing.T) {
+	type row struct {
+		ID    int            `csv:"id"`
+		Extra map[string]int `csv:"extra,json"`
+		Tags  *[]string      `csv:"tags,json"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	if err := writer.WriteAll([]row{{ID: 1, Extra: map[string]int{"k": 1}, Tags: &[]string{"a"}}, {ID: 2}}); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	expected := "id,extra,tags\n1,\"{\"\"k\"\":1}\",\"[\"\"a\"\"]\"\n2,null,\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterUnixTime(t *testing.T) {
+	type event struct {
+		At      time.Time `csv:"at,format=unix"`
+		AtMilli time.Time `csv:"at_ms,format=unixmilli"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	at := time.UnixMilli(1700000000123)
+	writer.Write(event{At: at, AtMilli: at})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "1700000000,1700000000123\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterIntegerBases(t *testing.T) {
+	type register struct {
+		Hex  int  `csv:"hex,base=16"`
+		Bits uint `csv:"bits,base=2"`
+		Auto int  `csv:"auto,base=0"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	writer.Write(register{Hex: 255, Bits: 5, Auto: 16})
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	expected := "ff,101,16\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestCSVWriterWriteAll(t *testing.T) {
+	type person struct {
+		Name string `csv:"name"`
+		Age  int    `csv:"age"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	people := []person{{"alice", 30}, {"bob", 25}}
+	if err := writer.WriteAll(&people); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "name,age\nalice,30\nbob,25\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	if err := writer.WriteAll([]*person{{"carol", 40}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "name,age\ncarol,40\n" {
+		t.Errorf("got %q for pointer elements", buf.String())
+	}
+
+	buf.Reset()
+	if err := writer.WriteAll([]person{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "name,age\n" {
+		t.Errorf("got %q for empty slice, want header only", buf.String())
+	}
+
+	for _, src := range []interface{}{person{}, []int{1}, nil, (*[]person)(nil)} {
+		if err := writer.WriteAll(src); err == nil {
+			t.Errorf("expected error for %T, got nil", src)
+		}
+	}
+}
+
+// failingWriter accepts limit bytes and then fails every write
+type failingWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.Len()+len(p) > f.limit {
+		return 0, errors.New("disk full")
+	}
+	return f.Buffer.Write(p)
+}
+
+func TestCSVWriterSetFlushEvery(t *testing.T) {
+	type row struct {
+		ID int `csv:"id"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	if err := writer.SetFlushEvery(-1); err == nil {
+		t.Error("expected a negative interval to be rejected")
+	}
+	if err := writer.SetFlushEvery(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, want := range []string{"", "1\n2\n", "1\n2\n", "1\n2\n3\n4\n"} {
+		if err := writer.Write(row{ID: i + 1}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if buf.String() != want {
+			t.Errorf("after row %d: got %q, want %q", i+1, buf.String(), want)
+		}
+	}
+
+	// The write error surfaces from the Write that flushes
+	writer = NewCSVWriter(&failingWriter{limit: 2})
+	if err := writer.SetFlushEvery(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Write(row{ID: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Write(row{ID: 2}); err == nil || err.Error() != "disk full" {
+		t.Errorf("expected the flush error from Write, got %v", err)
+	}
+}
+
+func TestCSVWriterSetOutputColumns(t *testing.T) {
+	type address struct {
+		City string `csv:"city"`
+	}
+	type person struct {
+		Name    string  `csv:"name"`
+		Age     int     `csv:"age"`
+		Email   string  `csv:"email"`
+		Address address `csv:"address"`
+	}
+
+	var buf bytes.Buffer
+	writer := NewCSVWriter(&buf)
+	if err := writer.SetOutputColumns("email", "address.city", "name"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	people := []person{{Name: "Alice", Age: 30, Email: "a@example.com", Address: address{City: "Oslo"}}}
+	if err := writer.WriteAll(people); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	expected := "email,address.city,name\na@example.com,Oslo,Alice\n"
+	if buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+
+	if err := writer.SetOutputColumns("name", "phone"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Write(people[0]); !errors.Is(err, ErrColumnMissing) {
+		t.Errorf("expected ErrColumnMissing for an unknown column, got %v", err)
+	}
+
+	for _, names := range [][]string{{"name", "name"}, {""}} {
+		if err := writer.SetOutputColumns(names...); err == nil {
+			t.Errorf("expected error for columns %q, got nil", names)
+		}
+	}
+
+	buf.Reset()
+	if err := writer.SetOutputColumns(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.WriteAll(people); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if expected := "name,age,email,address.city\nAlice,30,a@example.com,Oslo\n"; buf.String() != expected {
+		t.Errorf("got %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestNewCSVWriterAppend(t *testing.T) {
+	type entry struct {
+		Name  string `csv:"name"`
+		Count int    `csv:"count"`
+	}
+	path := filepath.Join(t.TempDir(), "log.csv")
+
+	for run, rows := range [][]entry{{{"a", 1}}, {{"b", 2}, {"c", 3}}} {
+		writer, err := NewCSVWriterAppend(path)
+		if err != nil {
+			t.Fatalf("run %d: failed to open: %v", run, err)
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			t.Fatalf("run %d: failed to write: %v", run, err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("run %d: failed to close: %v", run, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	if expected := "name,count\na,1\nb,2\nc,3\n"; string(data) != expected {
+		t.Errorf("got %q, want %q", data, expected)
+	}
+
+	// A file whose last line has no newline is terminated before appending
+	if err := os.WriteFile(path, []byte("name,count\na,1"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	writer, err := NewCSVWriterAppend(path)
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	writer.WriteHeader(entry{})
+	writer.Write(entry{"b", 2})
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if expected := "name,count\na,1\nb,2\n"; string(data) != expected {
+		t.Errorf("got %q, want %q", data, expected)
+	}
+
+	if _, err := NewCSVWriterAppend(filepath.Join(t.TempDir(), "missing", "log.csv")); err == nil {
+		t.Error("expected error for a missing directory, got nil")
+	}
+}
@@ -0,0 +1,19 @@
+//go:build zstd
+
+package gocsv
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	newZstdReader = func(src io.Reader) (io.ReadCloser, error) {
+		decoder, err := zstd.NewReader(src)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+}
@@ -0,0 +1,34 @@
+//go:build zstd
+
+package gocsv
+
+import (
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestWithDecompressionZstd(t *testing.T) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := encoder.EncodeAll([]byte("string_field,int_field\nAlice,30\n"), nil)
+
+	for _, compression := range []Compression{CompressionAuto, CompressionZstd} {
+		reader, err := NewCSVReaderBytes(data, WithDecompression(compression))
+		if err != nil {
+			t.Fatalf("%v: failed to create reader: %v", compression, err)
+		}
+		var row TestStruct
+		if err := reader.ReadNext(&row); err != nil {
+			t.Fatalf("%v: unexpected error: %v", compression, err)
+		}
+		if row.StringField != "Alice" || row.IntField != 30 {
+			t.Errorf("%v: unexpected value: %+v", compression, row)
+		}
+		if err := reader.Close(); err != nil {
+			t.Errorf("%v: unexpected close error: %v", compression, err)
+		}
+	}
+}